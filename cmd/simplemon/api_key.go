@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/go-chi/httplog"
+)
+
+// createAPIKeyHandler creates a key of the user, that is the user of the API key of the request
+// unless it is the admin. The key is only returned in this response.
+func (app *Application) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Create API Key Handler")
+
+	var input struct {
+		Name      string `json:"name"`
+		UserEmail string `json:"user_email"`
+	}
+	err := readJSON(w, r, &input)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	p := contextGetPrincipal(r)
+	if input.UserEmail == "" {
+		input.UserEmail = p.email
+	}
+	key, err := data.GenerateAPIKey(input.UserEmail, input.Name)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	v := validator.New()
+	data.ValidateAPIKey(v, *key)
+	p.validateOwner(v, key.UserEmail)
+	if !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid API key")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	created, err := app.apiKeys.Insert(r.Context(), *key, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	//the key itself must not be recorded
+	snapshot := *created
+	snapshot.Plaintext = ""
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditAPIKeyCreate, TargetType: "api_key", TargetID: strconv.FormatInt(created.APIKeyID, 10)}, nil, snapshot)
+	err = writeJSON(w, http.StatusCreated, created, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getAllAPIKeysHandler lists the keys of the user, without the keys themselves.
+func (app *Application) getAllAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get All API Keys Handler")
+
+	keys, err := app.apiKeys.GetAll(r.Context(), contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, keys, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteAPIKeyHandler revokes a key of the user, it can be the key of the request.
+func (app *Application) deleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Delete API Key Handler")
+
+	keyID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the api key id")
+		app.badRequestResponse(w, r, "The api key id must be an integer")
+		return
+	}
+	err = app.apiKeys.Delete(r.Context(), keyID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditAPIKeyDelete, TargetType: "api_key", TargetID: strconv.FormatInt(keyID, 10)}, nil, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
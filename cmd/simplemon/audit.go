@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog"
+)
+
+// recordAudit appends the change made by the request to the audit log, with the snapshots of the
+// target before and after it, nil when the target was created or deleted. The snapshots are
+// redacted by auditSnapshot, since the audit log can not be edited. The change is already done, so
+// an event that can not be recorded is only logged.
+func (app *Application) recordAudit(r *http.Request, event data.AuditEvent, before, after interface{}) {
+	if app.audit == nil {
+		return
+	}
+	log := httplog.LogEntry(r.Context())
+	p := contextGetPrincipal(r)
+	event.Actor, event.APIKeyID = p.email, p.apiKeyID
+	if p.admin {
+		event.Actor = data.AuditActorAdmin
+	}
+	event.RequestID = middleware.GetReqID(r.Context())
+	event.SourceIP = sourceIP(r)
+	var err error
+	if event.Before, err = json.Marshal(auditSnapshot(before)); err != nil {
+		log.Err(err).Str("action", event.Action).Msg("Error encoding the audit snapshot")
+		return
+	}
+	if event.After, err = json.Marshal(auditSnapshot(after)); err != nil {
+		log.Err(err).Str("action", event.Action).Msg("Error encoding the audit snapshot")
+		return
+	}
+	if err = app.audit.Insert(r.Context(), event, log); err != nil {
+		log.Err(err).Str("action", event.Action).Str("target_id", event.TargetID).Msg("Error recording the audit event")
+	}
+}
+
+// auditSnapshot returns the snapshot without the values that may contain credentials: the secret
+// of the channels, and the headers, body and parameters of the monitors, which are replaced by
+// notify.RedactedSecret when set.
+func auditSnapshot(snapshot interface{}) interface{} {
+	switch v := snapshot.(type) {
+	case *data.Monitor:
+		if v == nil {
+			return nil
+		}
+		return redactMonitor(*v)
+	case data.Monitor:
+		return redactMonitor(v)
+	case *data.Channel:
+		if v == nil {
+			return nil
+		}
+		return notify.Redact(*v)
+	case data.Channel:
+		return notify.Redact(v)
+	}
+	return snapshot
+}
+
+func redactMonitor(monitor data.Monitor) data.Monitor {
+	for _, value := range []*string{&monitor.Headers, &monitor.Body, &monitor.Parameters} {
+		if *value != "" {
+			*value = notify.RedactedSecret
+		}
+	}
+	return monitor
+}
+
+// sourceIP returns the address of the client of the request, without the port. The forwarding
+// headers are not trusted, since they can be set by the client.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// readAuditFilter reads the actor, action, since, until and limit query string parameters,
+// returning the problem of each invalid parameter.
+func readAuditFilter(r *http.Request) (data.AuditFilter, map[string]string) {
+	query := r.URL.Query()
+	filter := data.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Limit:  data.DefaultAuditEventLimit,
+	}
+	fields := map[string]string{}
+
+	if filter.Action != "" && !validAuditAction(filter.Action) {
+		fields["action"] = "must be one of " + strings.Join(data.AuditActions, ", ")
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				fields[name] = "must be a RFC 3339 time, like 2023-06-01T12:00:00Z"
+				continue
+			}
+			*dst = t
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		fields["until"] = "must be after since"
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > data.MaxAuditEventLimit {
+			fields["limit"] = "must be an integer between 1 and " + strconv.Itoa(data.MaxAuditEventLimit)
+		}
+	}
+	return filter, fields
+}
+
+func validAuditAction(action string) bool {
+	for _, value := range data.AuditActions {
+		if action == value {
+			return true
+		}
+	}
+	return false
+}
+
+// getAuditEventsHandler lists the events of the audit log, the most recent first. The users see
+// their own changes and the changes in the organizations they own.
+func (app *Application) getAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Audit Events Handler")
+
+	filter, fields := readAuditFilter(r)
+	if len(fields) > 0 {
+		log.Warn().Msg("Invalid query string parameters")
+		app.failedValidationResponse(w, r, fields)
+		return
+	}
+	filter.Owner = contextGetPrincipal(r).owner()
+	events, err := app.audit.GetAll(r.Context(), filter, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, events, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestApplication_auditMonitorDelete verifies that deleting a monitor records who deleted it, from
+// where, and the monitor before the deletion, without its headers.
+func TestApplication_auditMonitorDelete(t *testing.T) {
+	fields := initFields()
+	monitor := &data.Monitor{MonitorID: 7, OrgID: 3, URL: "https://www.google.com", Headers: `{"Authorization": "Bearer s3cret"}`}
+	monitors := data.NewMonitorModelMock()
+	monitors.On("GetById", mock.Anything, int64(7), mock.Anything, mock.Anything).Return(monitor, nil)
+	monitors.On("Delete", mock.Anything, int64(7), mock.Anything, mock.Anything).Return(nil)
+	orgs := data.NewOrgModelMock()
+	orgs.On("GetRole", mock.Anything, int64(3), "jojo@gmail.com", mock.Anything).Return(data.RoleEditor, nil)
+	audit := data.NewAuditModelMock()
+	audit.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	app := &Application{
+		config: fields.config,
+		logger: fields.logger,
+		models: monitors,
+		orgs:   orgs,
+		audit:  audit,
+	}
+	router := httprouter.New()
+	router.HandlerFunc("DELETE", "/v1/monitors/:id", app.deleteMonitorHandler)
+
+	req := asUser(httptest.NewRequest("DELETE", "/v1/monitors/7", nil), "jojo@gmail.com")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+	req.RemoteAddr = "203.0.113.9:52100"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 204 {
+		t.Fatalf("Expected status code %v, got %v: %s", 204, w.Code, w.Body.String())
+	}
+	before, _ := json.Marshal(data.Monitor{MonitorID: 7, OrgID: 3, URL: "https://www.google.com", Headers: notify.RedactedSecret})
+	audit.AssertCalled(t, "Insert", mock.Anything, data.AuditEvent{
+		Actor:      "jojo@gmail.com",
+		APIKeyID:   1,
+		Action:     data.AuditMonitorDelete,
+		TargetType: "monitor",
+		TargetID:   "7",
+		OrgID:      3,
+		Before:     before,
+		After:      json.RawMessage("null"),
+		RequestID:  "req-1",
+		SourceIP:   "203.0.113.9",
+	}, mock.Anything)
+}
+
+func TestAuditSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot interface{}
+		expected interface{}
+	}{
+		{
+			name:     "Test auditSnapshot monitor",
+			snapshot: &data.Monitor{MonitorID: 7, URL: "https://www.google.com", Body: `{"token": "s3cret"}`, Parameters: `{"key": "s3cret"}`},
+			expected: data.Monitor{MonitorID: 7, URL: "https://www.google.com", Body: notify.RedactedSecret, Parameters: notify.RedactedSecret},
+		},
+		{
+			name:     "Test auditSnapshot channel",
+			snapshot: data.Channel{ChannelID: 2, Kind: notify.ChannelWebhook, Config: json.RawMessage(`{"url":"https://example.com/hook","secret":"s3cret"}`)},
+			expected: data.Channel{ChannelID: 2, Kind: notify.ChannelWebhook, Config: json.RawMessage(`{"secret":"********","url":"https://example.com/hook"}`)},
+		},
+		{
+			name:     "Test auditSnapshot nil monitor",
+			snapshot: (*data.Monitor)(nil),
+			expected: nil,
+		},
+		{
+			name:     "Test auditSnapshot other snapshot",
+			snapshot: map[string]int64{"channel_id": 2},
+			expected: map[string]int64{"channel_id": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, auditSnapshot(tt.snapshot))
+		})
+	}
+}
+
+func TestApplication_getAuditEventsHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		admin              bool
+		expectedStatusCode int
+		expectedFilter     data.AuditFilter
+	}{
+		{
+			name:               "Test getAuditEventsHandler without filters",
+			expectedStatusCode: 200,
+			expectedFilter:     data.AuditFilter{Owner: "jojo@gmail.com", Limit: data.DefaultAuditEventLimit},
+		},
+		{
+			name:               "Test getAuditEventsHandler with all the filters",
+			query:              "?actor=dio@gmail.com&action=monitor.delete&since=2023-06-01T00:00:00Z&until=2023-06-02T00:00:00Z&limit=10",
+			admin:              true,
+			expectedStatusCode: 200,
+			expectedFilter: data.AuditFilter{
+				Actor:  "dio@gmail.com",
+				Action: data.AuditMonitorDelete,
+				Since:  time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+				Until:  time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC),
+				Limit:  10,
+			},
+		},
+		{
+			name:               "Test getAuditEventsHandler unknown action",
+			query:              "?action=monitor.drop",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test getAuditEventsHandler invalid time",
+			query:              "?since=yesterday",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test getAuditEventsHandler until before since",
+			query:              "?since=2023-06-02T00:00:00Z&until=2023-06-01T00:00:00Z",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test getAuditEventsHandler limit out of bounds",
+			query:              "?limit=5000",
+			expectedStatusCode: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			audit := data.NewAuditModelMock()
+			audit.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return([]data.AuditEvent{}, nil)
+			app := &Application{
+				config: fields.config,
+				logger: fields.logger,
+				audit:  audit,
+			}
+			req := asUser(httptest.NewRequest("GET", "/v1/audit"+tt.query, nil), "jojo@gmail.com")
+			if tt.admin {
+				req = asAdmin(req)
+			}
+			w := httptest.NewRecorder()
+			app.getAuditEventsHandler(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if w.Code == 200 {
+				audit.AssertCalled(t, "GetAll", mock.Anything, tt.expectedFilter, mock.Anything)
+			} else {
+				audit.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
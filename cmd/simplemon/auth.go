@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/go-chi/httplog"
+)
+
+// principal is the client authenticated by the API key of the request.
+type principal struct {
+	email    string // Email of the user of the API key, empty for the admin
+	apiKeyID int64
+	admin    bool // Authenticated by API_ADMIN_KEY, it can see and change the resources of all the users
+}
+
+// owner returns the owner argument of the models for the principal, see data.MonitorInterface.
+func (p *principal) owner() string {
+	if p.admin {
+		return ""
+	}
+	return p.email
+}
+
+// validateOwner verifies that the resource of the user can be created or changed by the principal.
+func (p *principal) validateOwner(v *validator.Validator, userEmail string) {
+	v.Check(p.admin || userEmail == p.email, "user_email", "must be the email of the API key")
+}
+
+type contextKey string
+
+const principalContextKey = contextKey("principal")
+
+func contextSetPrincipal(r *http.Request, p *principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey, p))
+}
+
+// contextGetPrincipal returns the principal set by the authenticate middleware. It panics when
+// there is none, since it means that the handler was registered without authentication.
+func contextGetPrincipal(r *http.Request) *principal {
+	p, ok := r.Context().Value(principalContextKey).(*principal)
+	if !ok {
+		panic("missing principal in request context")
+	}
+	return p
+}
+
+// authenticate reads the API key of the Authorization header (Bearer scheme), responding with 401
+// when it is missing or unknown.
+func (app *Application) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := httplog.LogEntry(r.Context())
+		w.Header().Add("Vary", "Authorization")
+
+		scheme, key, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || key == "" {
+			app.unauthorizedResponse(w, r, "The request must have an API key in the Authorization header, like Bearer <key>")
+			return
+		}
+		hash := data.HashAPIKey(key)
+		//the hashes have the same length, so the comparison does not leak the length of the admin key
+		if admin := app.config.authConfig.adminKey; admin != "" && subtle.ConstantTimeCompare(hash, data.HashAPIKey(admin)) == 1 {
+			next.ServeHTTP(w, contextSetPrincipal(r, &principal{admin: true}))
+			return
+		}
+		apiKey, err := app.apiKeys.GetByHash(r.Context(), hash, log)
+		if errors.Is(err, data.ErrNotFound) {
+			log.Warn().Msg("Unknown API key")
+			app.unauthorizedResponse(w, r, "The API key is invalid or was revoked")
+			return
+		} else if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, contextSetPrincipal(r, &principal{email: apiKey.UserEmail, apiKeyID: apiKey.APIKeyID}))
+	})
+}
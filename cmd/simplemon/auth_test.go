@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+)
+
+// asAdmin and asUser authenticate the request like the authenticate middleware, so the handlers can
+// be tested without API keys.
+func asAdmin(r *http.Request) *http.Request {
+	return contextSetPrincipal(r, &principal{admin: true})
+}
+
+func asUser(r *http.Request, email string) *http.Request {
+	return contextSetPrincipal(r, &principal{email: email, apiKeyID: 1})
+}
+
+func TestApplication_authenticate(t *testing.T) {
+	userKey, _ := data.GenerateAPIKey("jojo@gmail.com", "laptop")
+	tests := []struct {
+		name               string
+		authorization      string
+		getByHash          error
+		expectedStatusCode int
+		expectedPrincipal  principal
+	}{
+		{
+			name:               "Test authenticate api key",
+			authorization:      "Bearer " + userKey.Plaintext,
+			expectedStatusCode: 200,
+			expectedPrincipal:  principal{email: "jojo@gmail.com", apiKeyID: 1},
+		},
+		{
+			name:               "Test authenticate admin key",
+			authorization:      "Bearer admin-s3cret",
+			expectedStatusCode: 200,
+			expectedPrincipal:  principal{admin: true},
+		},
+		{
+			name:               "Test authenticate missing header",
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Test authenticate basic scheme",
+			authorization:      "Basic am9qbzpqb2pv",
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Test authenticate unknown key",
+			authorization:      "Bearer smk_unknown",
+			getByHash:          data.ErrAPIKeyNotFound,
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Test authenticate database generic error",
+			authorization:      "Bearer " + userKey.Plaintext,
+			getByHash:          errors.New("database generic error"),
+			expectedStatusCode: 500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			fields.config.authConfig.adminKey = "admin-s3cret"
+			apiKeys := data.NewAPIKeyModelMock()
+			apiKeys.On("GetByHash", mock.Anything, mock.Anything, mock.Anything).Return(&data.APIKey{APIKeyID: 1, UserEmail: "jojo@gmail.com"}, tt.getByHash)
+			app := &Application{
+				config:  fields.config,
+				logger:  fields.logger,
+				apiKeys: apiKeys,
+			}
+			var got *principal
+			handler := app.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = contextGetPrincipal(r)
+			}))
+
+			req := httptest.NewRequest("GET", "/v1/monitors", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if w.Code == 401 && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("Expected the WWW-Authenticate header, got %q", w.Header().Get("WWW-Authenticate"))
+			}
+			if w.Code == 200 && *got != tt.expectedPrincipal {
+				t.Errorf("Expected the principal %+v, got %+v", tt.expectedPrincipal, *got)
+			}
+			if tt.getByHash == nil && strings.HasPrefix(tt.authorization, "Bearer smk_") {
+				apiKeys.AssertCalled(t, "GetByHash", mock.Anything, userKey.Hash, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestApplication_createAPIKeyHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		admin              bool
+		expectedStatusCode int
+		expectedEmail      string
+	}{
+		{
+			name:               "Test createAPIKeyHandler for the user of the request",
+			body:               `{"name": "ci"}`,
+			expectedStatusCode: 201,
+			expectedEmail:      "jojo@gmail.com",
+		},
+		{
+			name:               "Test createAPIKeyHandler for another user",
+			body:               `{"name": "ci", "user_email": "dio@gmail.com"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test createAPIKeyHandler admin for another user",
+			body:               `{"name": "ci", "user_email": "dio@gmail.com"}`,
+			admin:              true,
+			expectedStatusCode: 201,
+			expectedEmail:      "dio@gmail.com",
+		},
+		{
+			name:               "Test createAPIKeyHandler admin without user",
+			body:               `{"name": "ci"}`,
+			admin:              true,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test createAPIKeyHandler key in the body",
+			body:               `{"name": "ci", "key": "smk_chosen"}`,
+			expectedStatusCode: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			apiKeys := data.NewAPIKeyModelMock()
+			apiKeys.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(&data.APIKey{APIKeyID: 1}, nil)
+			app := &Application{
+				config:  fields.config,
+				logger:  fields.logger,
+				apiKeys: apiKeys,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("POST", "/v1/api-keys", app.createAPIKeyHandler)
+
+			req := asUser(httptest.NewRequest("POST", "/v1/api-keys", strings.NewReader(tt.body)), "jojo@gmail.com")
+			if tt.admin {
+				req = asAdmin(req)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Fatalf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if w.Code != 201 {
+				return
+			}
+			apiKeys.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(key data.APIKey) bool {
+				return key.UserEmail == tt.expectedEmail && strings.HasPrefix(key.Plaintext, key.Prefix) &&
+					bytes.Equal(key.Hash, data.HashAPIKey(key.Plaintext))
+			}), mock.Anything)
+		})
+	}
+}
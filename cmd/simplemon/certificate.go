@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/go-chi/httplog"
+)
+
+// defaultExpiresWithinDays is the window used by the certificates list when the query string does
+// not have one.
+const defaultExpiresWithinDays = 30
+
+// getExpiringCertificatesHandler lists the last certificate seen by each monitor that expires
+// within the given number of days, including the ones already expired.
+func (app *Application) getExpiringCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Expiring Certificates Handler")
+
+	//read the query string filters
+	fields := map[string]string{}
+	query := r.URL.Query()
+	days := defaultExpiresWithinDays
+	if value := query.Get("expires_within_days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 0 || days > checker.MaxExpiryDays {
+			fields["expires_within_days"] = "must be an integer between 0 and " + strconv.Itoa(checker.MaxExpiryDays)
+		}
+	}
+	limit := data.DefaultCertificateLimit
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > data.MaxCertificateLimit {
+			fields["limit"] = "must be an integer between 1 and " + strconv.Itoa(data.MaxCertificateLimit)
+		}
+	}
+	if len(fields) > 0 {
+		log.Warn().Msg("Invalid query string parameters")
+		app.failedValidationResponse(w, r, fields)
+		return
+	}
+
+	before := time.Now().UTC().AddDate(0, 0, days)
+	certificates, err := app.results.GetExpiringCertificates(r.Context(), before, limit, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, certificates, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
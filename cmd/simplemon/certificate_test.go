@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_getExpiringCertificatesHandler(t *testing.T) {
+	type args struct {
+		expectedStatusCode int
+		query              string
+	}
+	type CertificatesReturn struct {
+		certificates []data.Certificate
+		err          error
+	}
+	tests := []struct {
+		name         string
+		fields       Fields
+		args         args
+		certificates CertificatesReturn
+		expectedDays int // verify the time sent to the GetExpiringCertificates method
+	}{
+		{
+			name:   "Test getExpiringCertificatesHandler success",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, query: "?expires_within_days=7&limit=10"},
+			certificates: CertificatesReturn{
+				certificates: []data.Certificate{
+					{MonitorID: 1, MonitorType: "tls", URL: "www.google.com", Issuer: "CN=GTS CA 1C3", ExpiresAt: time.Now().Add(72 * time.Hour)},
+				},
+			},
+			expectedDays: 7,
+		},
+		{
+			name:         "Test getExpiringCertificatesHandler default window",
+			fields:       Fields(initFields()),
+			args:         args{expectedStatusCode: 200},
+			certificates: CertificatesReturn{certificates: []data.Certificate{}},
+			expectedDays: defaultExpiresWithinDays,
+		},
+		{
+			name:   "Test getExpiringCertificatesHandler invalid window",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, query: "?expires_within_days=-1"},
+		},
+		{
+			name:   "Test getExpiringCertificatesHandler limit out of range",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, query: "?limit=0"},
+		},
+		{
+			name:         "Test getExpiringCertificatesHandler database generic error",
+			fields:       Fields(initFields()),
+			args:         args{expectedStatusCode: 500},
+			certificates: CertificatesReturn{err: errors.New("database generic error")},
+			expectedDays: defaultExpiresWithinDays,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := data.NewCheckResultModelMock()
+			before := mock.MatchedBy(func(before time.Time) bool {
+				expected := time.Now().UTC().AddDate(0, 0, tt.expectedDays)
+				return before.Sub(expected).Abs() < time.Minute
+			})
+			results.On("GetExpiringCertificates", mock.Anything, before, mock.Anything, "", mock.Anything).Return(tt.certificates.certificates, tt.certificates.err)
+			app := &Application{
+				config:  tt.fields.config,
+				logger:  tt.fields.logger,
+				results: results,
+			}
+			req := asAdmin(httptest.NewRequest("GET", "/v1/certificates"+tt.args.query, nil))
+			w := httptest.NewRecorder()
+			http.HandlerFunc(app.getExpiringCertificatesHandler).ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
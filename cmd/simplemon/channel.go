@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/go-chi/httplog"
+)
+
+// validateChannel verifies the fields shared by all the channel kinds and the config of its kind,
+// collecting the errors of all the fields.
+func (app *Application) validateChannel(channel data.Channel) *validator.Validator {
+	v := validator.New()
+	data.ValidateChannel(v, channel)
+	if channel.Kind == "" {
+		return v
+	}
+	var validationErr *data.ValidationError
+	err := app.senders.Validate(channel)
+	switch {
+	case errors.As(err, &validationErr):
+		v.Merge(validationErr.Fields)
+	case err != nil:
+		v.AddError("kind", err.Error())
+	}
+	return v
+}
+
+// setChannelDefaults sets the fields of the channel that the user of the request can omit, like
+// setMonitorDefaults: the user_email is the email of the user, and the org_id is its organization
+// when it has only one.
+func (app *Application) setChannelDefaults(r *http.Request, channel *data.Channel) error {
+	if channel.UserEmail == "" {
+		channel.UserEmail = contextGetPrincipal(r).email
+	}
+	if channel.OrgID != 0 {
+		return nil
+	}
+	var err error
+	channel.OrgID, err = app.defaultOrgID(r)
+	return err
+}
+
+func (app *Application) createChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Create Channel Handler")
+
+	var channel data.Channel
+	err := readJSON(w, r, &channel)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	if err := app.setChannelDefaults(r, &channel); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if v := app.validateChannel(channel); !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid channel")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	if !app.requireRole(w, r, channel.OrgID, data.RoleEditor) || !app.requireMember(w, r, channel.OrgID, channel.UserEmail) {
+		return
+	}
+	created, err := app.channels.Insert(r.Context(), channel, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditChannelCreate, TargetType: "channel", TargetID: strconv.FormatInt(created.ChannelID, 10), OrgID: created.OrgID}, nil, created)
+	err = writeJSON(w, http.StatusCreated, notify.Redact(*created), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) getAllChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get All Channels Handler")
+
+	channels, err := app.channels.GetAll(r.Context(), contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, redactChannels(channels), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) getChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Channel Handler")
+
+	channelID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the channel id")
+		app.badRequestResponse(w, r, "The channel id must be an integer")
+		return
+	}
+	channel, err := app.channels.GetByID(r.Context(), channelID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, notify.Redact(*channel), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateChannelHandler replaces the name, kind and config of the channel (PUT). The redacted
+// secrets of the config are kept, so a channel can be sent back as it was returned. The organization
+// of the channel can not be changed, except for the channels of no organization.
+func (app *Application) updateChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Update Channel Handler")
+
+	channelID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the channel id")
+		app.badRequestResponse(w, r, "The channel id must be an integer")
+		return
+	}
+	var channel data.Channel
+	err = readJSON(w, r, &channel)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	current, err := app.channels.GetByID(r.Context(), channelID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, current.OrgID, data.RoleEditor) {
+		return
+	}
+	channel = notify.KeepSecrets(channel, *current)
+	channel.ChannelID = channelID
+	//the channel stays in its organization when the org_id is omitted
+	if channel.OrgID == 0 {
+		channel.OrgID = current.OrgID
+	}
+	if err := app.setChannelDefaults(r, &channel); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	v := app.validateChannel(channel)
+	//the monitors of the organization linked to the channel would alert another organization
+	v.Check(current.OrgID == 0 || channel.OrgID == current.OrgID, "org_id", "can not be changed")
+	if !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid channel")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	if channel.OrgID != current.OrgID && !app.requireRole(w, r, channel.OrgID, data.RoleEditor) {
+		return
+	}
+	if (channel.UserEmail != current.UserEmail || channel.OrgID != current.OrgID) && !app.requireMember(w, r, channel.OrgID, channel.UserEmail) {
+		return
+	}
+	updated, err := app.channels.Update(r.Context(), channel, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditChannelUpdate, TargetType: "channel", TargetID: strconv.FormatInt(channelID, 10), OrgID: updated.OrgID}, current, updated)
+	err = writeJSON(w, http.StatusOK, notify.Redact(*updated), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) deleteChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Delete Channel Handler")
+
+	channelID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the channel id")
+		app.badRequestResponse(w, r, "The channel id must be an integer")
+		return
+	}
+	channel, err := app.channels.GetByID(r.Context(), channelID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, channel.OrgID, data.RoleEditor) {
+		return
+	}
+	err = app.channels.Delete(r.Context(), channelID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditChannelDelete, TargetType: "channel", TargetID: strconv.FormatInt(channelID, 10), OrgID: channel.OrgID}, channel, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// testChannelHandler sends a sample incident event to the channel and waits for the delivery, so
+// the client knows if the channel works.
+func (app *Application) testChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Test Channel Handler")
+
+	channelID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the channel id")
+		app.badRequestResponse(w, r, "The channel id must be an integer")
+		return
+	}
+	channel, err := app.channels.GetByID(r.Context(), channelID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, channel.OrgID, data.RoleEditor) {
+		return
+	}
+	notifier, err := app.senders.Notifier(*channel)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error creating the notifier of the channel")
+		app.notifyFailedResponse(w, r, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), notifyTimeout)
+	defer cancel()
+	err = notifier.Notify(ctx, notify.NewTestEvent())
+	if err != nil {
+		log.Warn().Err(err).Msg("Error sending the test notification")
+		app.notifyFailedResponse(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *Application) getMonitorChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Monitor Channels Handler")
+
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
+	//Verify if the monitor exists
+	_, err = app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	channels, err := app.channels.GetByMonitor(r.Context(), monitorID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, redactChannels(channels), nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// linkMonitorChannelHandler makes the monitor alert the channel (PUT), it can be repeated.
+func (app *Application) linkMonitorChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Link Monitor Channel Handler")
+
+	monitorID, channelID, ok := app.readMonitorChannelParams(w, r)
+	if !ok {
+		return
+	}
+	monitor, err := app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, monitor.OrgID, data.RoleEditor) {
+		return
+	}
+	channel, err := app.channels.GetByID(r.Context(), channelID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	//a member of both organizations could send the alerts of one to the other
+	if channel.OrgID != monitor.OrgID {
+		app.modelErrorResponse(w, r, data.ErrChannelOtherOrg)
+		return
+	}
+	err = app.channels.Link(r.Context(), monitorID, channelID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditMonitorLink, TargetType: "monitor", TargetID: strconv.FormatInt(monitorID, 10), OrgID: monitor.OrgID},
+		nil, map[string]int64{"channel_id": channelID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *Application) unlinkMonitorChannelHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Unlink Monitor Channel Handler")
+
+	monitorID, channelID, ok := app.readMonitorChannelParams(w, r)
+	if !ok {
+		return
+	}
+	monitor, err := app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, monitor.OrgID, data.RoleEditor) {
+		return
+	}
+	err = app.channels.Unlink(r.Context(), monitorID, channelID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditMonitorUnlink, TargetType: "monitor", TargetID: strconv.FormatInt(monitorID, 10), OrgID: monitor.OrgID},
+		map[string]int64{"channel_id": channelID}, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readMonitorChannelParams reads the ids of /v1/monitors/:id/channels/:channel_id, responding with
+// 400 when they are invalid.
+func (app *Application) readMonitorChannelParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	log := httplog.LogEntry(r.Context())
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return 0, 0, false
+	}
+	channelID, err := readInt64Param(r, "channel_id")
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the channel id")
+		app.badRequestResponse(w, r, "The channel id must be an integer")
+		return 0, 0, false
+	}
+	return monitorID, channelID, true
+}
+
+// redactChannels redacts the secrets of the channels returned by the API.
+func redactChannels(channels []data.Channel) []data.Channel {
+	redacted := make([]data.Channel, len(channels))
+	for i, channel := range channels {
+		redacted[i] = notify.Redact(channel)
+	}
+	return redacted
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_createChannelHandler(t *testing.T) {
+	type args struct {
+		body               string
+		expectedStatusCode int
+		invalidFields      []string
+	}
+	tests := []struct {
+		name   string
+		fields Fields
+		args   args
+		insert error
+	}{
+		{
+			name:   "Test createChannelHandler email",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "on-call", "kind": "email", "config": {"addresses": ["jojo@gmail.com", "oncall@example.com"]}}`,
+				expectedStatusCode: 201,
+			},
+		},
+		{
+			name:   "Test createChannelHandler webhook",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "tooling", "kind": "webhook", "config": {"url": "https://hooks.example.com/simplemon", "secret": "s3cret"}}`,
+				expectedStatusCode: 201,
+			},
+		},
+		{
+			name:   "Test createChannelHandler chat webhook",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "#alerts", "kind": "chat-webhook", "config": {"url": "https://hooks.slack.com/services/T0/B0/X"}}`,
+				expectedStatusCode: 201,
+			},
+		},
+		{
+			name:   "Test createChannelHandler required fields",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{}`,
+				expectedStatusCode: 400,
+				invalidFields:      []string{"name", "kind", "org_id"},
+			},
+		},
+		{
+			name:   "Test createChannelHandler unknown kind",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "pager", "kind": "sms"}`,
+				expectedStatusCode: 400,
+				invalidFields:      []string{"kind"},
+			},
+		},
+		{
+			name:   "Test createChannelHandler invalid email address",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "on-call", "kind": "email", "config": {"addresses": ["jojo"]}}`,
+				expectedStatusCode: 400,
+				invalidFields:      []string{"config"},
+			},
+		},
+		{
+			name:   "Test createChannelHandler webhook without url",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "tooling", "kind": "webhook", "config": {"secret": "s3cret"}}`,
+				expectedStatusCode: 400,
+				invalidFields:      []string{"config"},
+			},
+		},
+		{
+			name:   "Test createChannelHandler unknown config field",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "#alerts", "kind": "chat-webhook", "config": {"url": "https://hooks.slack.com/services/T0/B0/X", "secret": "s3cret"}}`,
+				expectedStatusCode: 400,
+				invalidFields:      []string{"config"},
+			},
+		},
+		{
+			name:   "Test createChannelHandler database generic error",
+			fields: Fields(initFields()),
+			args: args{
+				body:               `{"org_id": 1, "name": "tooling", "kind": "webhook", "config": {"url": "https://hooks.example.com/simplemon"}}`,
+				expectedStatusCode: 500,
+			},
+			insert: errors.New("database generic error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels := data.NewChannelModelMock()
+			channels.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(&data.Channel{ChannelID: 1}, tt.insert)
+			orgs := data.NewOrgModelMock()
+			orgs.On("GetAll", mock.Anything, "jojo@gmail.com", mock.Anything).Return([]data.Org{}, nil)
+			orgs.On("GetRole", mock.Anything, int64(1), "jojo@gmail.com", mock.Anything).Return(data.RoleEditor, nil)
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				channels: channels,
+				orgs:     orgs,
+				senders:  notify.NewChannels(nil, ""),
+			}
+			router := httprouter.New()
+			router.HandlerFunc("POST", "/v1/channels", app.createChannelHandler)
+
+			req := asUser(httptest.NewRequest("POST", "/v1/channels", strings.NewReader(tt.args.body)), "jojo@gmail.com")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.args.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if len(tt.args.invalidFields) > 0 {
+				var p problem
+				if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+					t.Fatal(err)
+				}
+				if len(p.Errors) != len(tt.args.invalidFields) {
+					t.Errorf("Expected errors of %v, got %v", tt.args.invalidFields, p.Errors)
+				}
+				for _, field := range tt.args.invalidFields {
+					if p.Errors[field] == "" {
+						t.Errorf("Expected an error for the field %s, got %v", field, p.Errors)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_testChannelHandler(t *testing.T) {
+	var received []notify.WebhookPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		var payload notify.WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+	}))
+	defer receiver.Close()
+
+	type GetReturn struct {
+		channel *data.Channel
+		err     error
+	}
+	tests := []struct {
+		name               string
+		fields             Fields
+		get                GetReturn
+		expectedStatusCode int
+		expectedReceived   int
+	}{
+		{
+			name:               "Test testChannelHandler webhook delivered",
+			fields:             Fields(initFields()),
+			get:                GetReturn{channel: &data.Channel{ChannelID: 1, Kind: notify.ChannelWebhook, Config: json.RawMessage(`{"url": "` + receiver.URL + `"}`)}},
+			expectedStatusCode: 204,
+			expectedReceived:   1,
+		},
+		{
+			name:               "Test testChannelHandler webhook rejected",
+			fields:             Fields(initFields()),
+			get:                GetReturn{channel: &data.Channel{ChannelID: 1, Kind: notify.ChannelWebhook, Config: json.RawMessage(`{"url": "` + receiver.URL + `/gone"}`)}},
+			expectedStatusCode: 502,
+		},
+		{
+			name:               "Test testChannelHandler email disabled",
+			fields:             Fields(initFields()),
+			get:                GetReturn{channel: &data.Channel{ChannelID: 1, Kind: notify.ChannelEmail, Config: json.RawMessage(`{"addresses": ["jojo@gmail.com"]}`)}},
+			expectedStatusCode: 502,
+		},
+		{
+			name:               "Test testChannelHandler channel not found",
+			fields:             Fields(initFields()),
+			get:                GetReturn{err: data.ErrChannelNotFound},
+			expectedStatusCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			channels := data.NewChannelModelMock()
+			channels.On("GetByID", mock.Anything, int64(1), "", mock.Anything).Return(tt.get.channel, tt.get.err)
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				channels: channels,
+				senders:  notify.NewChannels(nil, ""),
+			}
+			router := httprouter.New()
+			router.HandlerFunc("POST", "/v1/channels/:id/test", app.testChannelHandler)
+
+			req := asAdmin(httptest.NewRequest("POST", "/v1/channels/1/test", nil))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if len(received) != tt.expectedReceived {
+				t.Fatalf("Expected %v notifications, got %v", tt.expectedReceived, len(received))
+			}
+			for _, payload := range received {
+				if !payload.Test || payload.Type != notify.EventIncidentOpened {
+					t.Errorf("Expected a test incident.opened event, got %+v", payload)
+				}
+			}
+		})
+	}
+}
+
+func TestApplication_linkMonitorChannelHandler(t *testing.T) {
+	type GetReturn struct {
+		err error
+	}
+	tests := []struct {
+		name               string
+		fields             Fields
+		method             string
+		path               string
+		getMonitor         GetReturn
+		getChannel         GetReturn
+		link               error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Test linkMonitorChannelHandler linked",
+			fields:             Fields(initFields()),
+			method:             "PUT",
+			path:               "/v1/monitors/1/channels/2",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Test linkMonitorChannelHandler monitor not found",
+			fields:             Fields(initFields()),
+			method:             "PUT",
+			path:               "/v1/monitors/1/channels/2",
+			getMonitor:         GetReturn{err: data.ErrMonitorNotFound},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Test linkMonitorChannelHandler channel not found",
+			fields:             Fields(initFields()),
+			method:             "PUT",
+			path:               "/v1/monitors/1/channels/2",
+			getChannel:         GetReturn{err: data.ErrChannelNotFound},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Test linkMonitorChannelHandler channel of another organization",
+			fields:             Fields(initFields()),
+			method:             "PUT",
+			path:               "/v1/monitors/1/channels/3",
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Test linkMonitorChannelHandler invalid channel id",
+			fields:             Fields(initFields()),
+			method:             "PUT",
+			path:               "/v1/monitors/1/channels/jojo",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test unlinkMonitorChannelHandler unlinked",
+			fields:             Fields(initFields()),
+			method:             "DELETE",
+			path:               "/v1/monitors/1/channels/2",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Test unlinkMonitorChannelHandler not linked",
+			fields:             Fields(initFields()),
+			method:             "DELETE",
+			path:               "/v1/monitors/1/channels/2",
+			link:               data.ErrChannelLinkNotFound,
+			expectedStatusCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitors := data.NewMonitorModelMock()
+			monitors.On("GetById", mock.Anything, int64(1), "", mock.Anything).Return(&data.Monitor{MonitorID: 1, OrgID: 1}, tt.getMonitor.err)
+			channels := data.NewChannelModelMock()
+			channels.On("GetByID", mock.Anything, int64(2), "", mock.Anything).Return(&data.Channel{ChannelID: 2, OrgID: 1}, tt.getChannel.err)
+			channels.On("GetByID", mock.Anything, int64(3), "", mock.Anything).Return(&data.Channel{ChannelID: 3, OrgID: 2}, nil)
+			channels.On("Link", mock.Anything, int64(1), int64(3), mock.Anything).Return(nil)
+			channels.On("Link", mock.Anything, int64(1), int64(2), mock.Anything).Return(tt.link)
+			channels.On("Unlink", mock.Anything, int64(1), int64(2), mock.Anything).Return(tt.link)
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				models:   monitors,
+				channels: channels,
+				senders:  notify.NewChannels(nil, ""),
+			}
+			router := httprouter.New()
+			router.HandlerFunc("PUT", "/v1/monitors/:id/channels/:channel_id", app.linkMonitorChannelHandler)
+			router.HandlerFunc("DELETE", "/v1/monitors/:id/channels/:channel_id", app.unlinkMonitorChannelHandler)
+
+			req := asAdmin(httptest.NewRequest(tt.method, tt.path, nil))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
+
+// TestApplication_channelSecrets verifies that the secrets of the channels are never returned, and
+// that sending back a channel as it was returned keeps its secrets.
+func TestApplication_channelSecrets(t *testing.T) {
+	fields := initFields()
+	webhookConfig := json.RawMessage(`{"url": "https://hooks.example.com/simplemon?token=t0ken", "secret": "s3cret"}`)
+	chatConfig := json.RawMessage(`{"url": "https://hooks.slack.com/services/T0/B0/t0ken"}`)
+	channels := data.NewChannelModelMock()
+	channels.On("GetAll", mock.Anything, "", mock.Anything).Return([]data.Channel{
+		{ChannelID: 1, Kind: notify.ChannelWebhook, Config: webhookConfig},
+		{ChannelID: 2, Kind: notify.ChannelChatWebhook, Config: chatConfig},
+	}, nil)
+	channels.On("GetByID", mock.Anything, int64(1), "", mock.Anything).Return(&data.Channel{ChannelID: 1, OrgID: 1, UserEmail: "jojo@gmail.com", Name: "tooling", Kind: notify.ChannelWebhook, Config: webhookConfig}, nil)
+	channels.On("Update", mock.Anything, mock.Anything, "", mock.Anything).Return(&data.Channel{ChannelID: 1, Kind: notify.ChannelWebhook, Config: webhookConfig}, nil)
+	app := &Application{
+		config:   fields.config,
+		logger:   fields.logger,
+		channels: channels,
+		senders:  notify.NewChannels(nil, ""),
+	}
+	router := httprouter.New()
+	router.HandlerFunc("GET", "/v1/channels", app.getAllChannelsHandler)
+	router.HandlerFunc("GET", "/v1/channels/:id", app.getChannelHandler)
+	router.HandlerFunc("PUT", "/v1/channels/:id", app.updateChannelHandler)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+	}{
+		{name: "Test list the channels", method: "GET", path: "/v1/channels", expectedStatusCode: 200},
+		{name: "Test get a channel", method: "GET", path: "/v1/channels/1", expectedStatusCode: 200},
+		{
+			name:               "Test update a channel with its redacted secrets",
+			method:             "PUT",
+			path:               "/v1/channels/1",
+			body:               `{"user_email": "jojo@gmail.com", "name": "ops", "kind": "webhook", "config": {"url": "https://hooks.example.com/********", "secret": "********"}}`,
+			expectedStatusCode: 200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asAdmin(httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "s3cret") || strings.Contains(w.Body.String(), "t0ken") {
+				t.Errorf("Expected the secrets to be redacted, got %s", w.Body.String())
+			}
+		})
+	}
+	channels.AssertCalled(t, "Update", mock.Anything, mock.MatchedBy(func(c data.Channel) bool {
+		return c.Name == "ops" && string(c.Config) == `{"secret":"s3cret","url":"https://hooks.example.com/simplemon?token=t0ken"}`
+	}), "", mock.Anything)
+}
+
+// TestApplication_channelRoles verifies that the members of an organization can read its channels,
+// but only the editors and the owners can change, test and link them, to the members and the
+// monitors of the organization.
+func TestApplication_channelRoles(t *testing.T) {
+	fields := initFields()
+	monitors := data.NewMonitorModelMock()
+	monitors.On("GetById", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(&data.Monitor{MonitorID: 1, OrgID: 1}, nil)
+	channels := data.NewChannelModelMock()
+	channels.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(&data.Channel{ChannelID: 1, OrgID: 1}, nil)
+	channels.On("GetAll", mock.Anything, "viewer@gmail.com", mock.Anything).Return([]data.Channel{{ChannelID: 1, OrgID: 1}}, nil)
+	channels.On("GetByID", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(&data.Channel{ChannelID: 1, OrgID: 1, UserEmail: "editor@gmail.com", Name: "ops", Kind: notify.ChannelEmail}, nil)
+	channels.On("GetByID", mock.Anything, int64(2), mock.Anything, mock.Anything).Return((*data.Channel)(nil), data.ErrChannelNotFound)
+	channels.On("Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&data.Channel{ChannelID: 1, OrgID: 1}, nil)
+	channels.On("Delete", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil)
+	channels.On("Link", mock.Anything, int64(1), int64(1), mock.Anything).Return(nil)
+	orgs := data.NewOrgModelMock()
+	orgs.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return([]data.Org{{OrgID: 1}}, nil)
+	orgs.On("GetRole", mock.Anything, int64(1), "viewer@gmail.com", mock.Anything).Return(data.RoleViewer, nil)
+	orgs.On("GetRole", mock.Anything, int64(1), "editor@gmail.com", mock.Anything).Return(data.RoleEditor, nil)
+	orgs.On("GetRole", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(data.Role(""), data.ErrOrgNotFound)
+	app := &Application{
+		config:   fields.config,
+		logger:   fields.logger,
+		models:   monitors,
+		channels: channels,
+		orgs:     orgs,
+		senders:  notify.NewChannels(nil, ""),
+	}
+	router := httprouter.New()
+	router.HandlerFunc("POST", "/v1/channels", app.createChannelHandler)
+	router.HandlerFunc("GET", "/v1/channels", app.getAllChannelsHandler)
+	router.HandlerFunc("GET", "/v1/channels/:id", app.getChannelHandler)
+	router.HandlerFunc("PUT", "/v1/channels/:id", app.updateChannelHandler)
+	router.HandlerFunc("DELETE", "/v1/channels/:id", app.deleteChannelHandler)
+	router.HandlerFunc("POST", "/v1/channels/:id/test", app.testChannelHandler)
+	router.HandlerFunc("PUT", "/v1/monitors/:id/channels/:channel_id", app.linkMonitorChannelHandler)
+
+	createBody := `{"name": "ops", "kind": "email", "config": {"addresses": ["ops@example.com"]}}`
+	updateBody := `{"name": "ops", "kind": "email", "config": {"addresses": ["ops@example.com"]}}`
+	tests := []struct {
+		name               string
+		user               string
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+	}{
+		{name: "Test viewer lists the channels", user: "viewer@gmail.com", method: "GET", path: "/v1/channels", expectedStatusCode: 200},
+		{name: "Test viewer gets a channel", user: "viewer@gmail.com", method: "GET", path: "/v1/channels/1", expectedStatusCode: 200},
+		{name: "Test viewer can not create a channel", user: "viewer@gmail.com", method: "POST", path: "/v1/channels", body: createBody, expectedStatusCode: 403},
+		{name: "Test viewer can not change a channel", user: "viewer@gmail.com", method: "PUT", path: "/v1/channels/1", body: updateBody, expectedStatusCode: 403},
+		{name: "Test viewer can not delete a channel", user: "viewer@gmail.com", method: "DELETE", path: "/v1/channels/1", expectedStatusCode: 403},
+		{name: "Test viewer can not test a channel", user: "viewer@gmail.com", method: "POST", path: "/v1/channels/1/test", expectedStatusCode: 403},
+		{name: "Test viewer can not link a channel", user: "viewer@gmail.com", method: "PUT", path: "/v1/monitors/1/channels/1", expectedStatusCode: 403},
+		{name: "Test channel of another organization", user: "editor@gmail.com", method: "GET", path: "/v1/channels/2", expectedStatusCode: 404},
+		{name: "Test editor creates a channel", user: "editor@gmail.com", method: "POST", path: "/v1/channels", body: createBody, expectedStatusCode: 201},
+		{
+			name:               "Test editor can not create a channel of a user out of the organization",
+			user:               "editor@gmail.com",
+			method:             "POST",
+			path:               "/v1/channels",
+			body:               `{"user_email": "lulu@gmail.com", "name": "ops", "kind": "email", "config": {"addresses": ["ops@example.com"]}}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test editor can not move a channel to another organization",
+			user:               "editor@gmail.com",
+			method:             "PUT",
+			path:               "/v1/channels/1",
+			body:               `{"org_id": 2, "name": "ops", "kind": "email", "config": {"addresses": ["ops@example.com"]}}`,
+			expectedStatusCode: 400,
+		},
+		{name: "Test editor changes a channel", user: "editor@gmail.com", method: "PUT", path: "/v1/channels/1", body: updateBody, expectedStatusCode: 200},
+		{name: "Test editor deletes a channel", user: "editor@gmail.com", method: "DELETE", path: "/v1/channels/1", expectedStatusCode: 204},
+		{name: "Test editor links a channel", user: "editor@gmail.com", method: "PUT", path: "/v1/monitors/1/channels/1", expectedStatusCode: 204},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asUser(httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)), tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
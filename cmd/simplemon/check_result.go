@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/go-chi/httplog"
+)
+
+func (app *Application) getMonitorResultsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Monitor Results Handler")
+
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
+
+	//read the query string filters
+	var filter data.CheckResultFilter
+	fields := map[string]string{}
+	query := r.URL.Query()
+	if from := query.Get("from"); from != "" {
+		filter.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			fields["from"] = "must be a RFC 3339 timestamp"
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		filter.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			fields["to"] = "must be a RFC 3339 timestamp"
+		}
+	}
+	filter.Limit = data.DefaultCheckResultLimit
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > data.MaxCheckResultLimit {
+			fields["limit"] = "must be an integer between 1 and " + strconv.Itoa(data.MaxCheckResultLimit)
+		}
+	}
+	if len(fields) > 0 {
+		log.Warn().Msg("Invalid query string parameters")
+		app.failedValidationResponse(w, r, fields)
+		return
+	}
+
+	//Verify if the monitor exists
+	_, err = app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+
+	results, err := app.results.GetByMonitor(r.Context(), monitorID, filter, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, results, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_getMonitorResultsHandler(t *testing.T) {
+	type args struct {
+		expectedStatusCode int
+		query              string
+		monitor_id         string
+	}
+	type GetReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	type ResultsReturn struct {
+		results []data.CheckResult
+		err     error
+	}
+	monitor := &data.Monitor{MonitorID: 1, URL: "https://www.google.com", UserEmail: "jojo@gmail.com", MonitorType: "http", Method: "GET"}
+	tests := []struct {
+		name    string
+		fields  Fields
+		args    args
+		get     GetReturn
+		results ResultsReturn
+	}{
+		{
+			name:   "Test getMonitorResultsHandler success",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 200,
+				query:              "?from=2023-01-01T00:00:00Z&to=2023-01-02T00:00:00Z&limit=10",
+				monitor_id:         "1",
+			},
+			get: GetReturn{monitor: monitor},
+			results: ResultsReturn{
+				results: []data.CheckResult{
+					{CheckResultID: 2, MonitorID: 1, CheckedAt: time.Now(), Success: true, StatusCode: 200, LatencyMs: 30},
+					{CheckResultID: 1, MonitorID: 1, CheckedAt: time.Now(), Success: false, ErrorClass: "timeout"},
+				},
+			},
+		},
+		{
+			name:   "Test getMonitorResultsHandler invalid monitor id",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 400,
+				monitor_id:         "invalid",
+			},
+		},
+		{
+			name:   "Test getMonitorResultsHandler invalid from",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 400,
+				query:              "?from=yesterday",
+				monitor_id:         "1",
+			},
+		},
+		{
+			name:   "Test getMonitorResultsHandler limit out of range",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 400,
+				query:              "?limit=100000",
+				monitor_id:         "1",
+			},
+		},
+		{
+			name:   "Test getMonitorResultsHandler monitor not found",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 404,
+				monitor_id:         "1",
+			},
+			get: GetReturn{err: data.ErrMonitorNotFound},
+		},
+		{
+			name:   "Test getMonitorResultsHandler database generic error",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 500,
+				monitor_id:         "1",
+			},
+			get:     GetReturn{monitor: monitor},
+			results: ResultsReturn{err: errors.New("database generic error")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitors := data.NewMonitorModelMock()
+			monitors.On("GetById", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
+			results := data.NewCheckResultModelMock()
+			results.On("GetByMonitor", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(tt.results.results, tt.results.err)
+			app := &Application{
+				config:  tt.fields.config,
+				logger:  tt.fields.logger,
+				models:  monitors,
+				results: results,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("GET", "/v1/monitors/:id/results", app.getMonitorResultsHandler)
+
+			req := asAdmin(httptest.NewRequest("GET", "/v1/monitors/"+tt.args.monitor_id+"/results"+tt.args.query, nil))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
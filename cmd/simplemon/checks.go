@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/rs/zerolog"
+)
+
+// notifyTimeout limits the time spent delivering each notification.
+const notifyTimeout = 30 * time.Second
+
+// runCheck is called by the scheduler every time a monitor must be checked.
+func (app *Application) runCheck(ctx context.Context, monitor data.Monitor) {
+	log := app.logger.With().Int64("monitor_id", monitor.MonitorID).Logger()
+	log.Debug().Msg("Running check")
+
+	result := app.checkers.Check(ctx, monitor)
+	if !result.Success {
+		log.Warn().
+			Int("status_code", result.StatusCode).
+			Dur("latency", result.Latency).
+			Str("resolved_ip", result.ResolvedIP).
+			Str("error_class", string(result.ErrorClass)).
+			Str("error", result.Error).
+			Msg("Check failed")
+	} else {
+		log.Info().
+			Int("status_code", result.StatusCode).
+			Dur("latency", result.Latency).
+			Int64("response_size", result.ResponseSize).
+			Msg("Check finished")
+	}
+
+	if ctx.Err() != nil {
+		//the monitor was deleted, or the server is stopping, during the check
+		log.Debug().Msg("Check canceled, the result is not recorded")
+		return
+	}
+	app.metrics.observeCheck(monitor, result)
+
+	checkResult := newCheckResult(result)
+	_, err := app.results.Insert(ctx, checkResult, log)
+	if err != nil {
+		log.Err(err).Msg("Error saving the check result")
+	}
+
+	event, err := app.tracker.Observe(ctx, monitor, checkResult, log)
+	if err != nil {
+		log.Err(err).Msg("Error updating the incidents")
+		return
+	}
+	if event != nil {
+		log.Info().Int64("incident_id", event.Incident.IncidentID).Msgf("Incident event: %s", event.Type)
+		app.notify(ctx, notify.NewIncidentEvent(*event), log)
+	}
+}
+
+// stopChecks stops checking a deleted monitor right away instead of at the next resync of the
+// scheduler, forgets its metrics and resolves its open incident, since no check will resolve it.
+func (app *Application) stopChecks(ctx context.Context, monitor data.Monitor, log zerolog.Logger) {
+	if app.scheduler != nil {
+		app.scheduler.Remove(monitor.MonitorID)
+	}
+	app.metrics.forgetMonitor(monitor.MonitorID)
+	if app.tracker == nil {
+		return
+	}
+	event, err := app.tracker.Stop(ctx, monitor, time.Now().UTC(), log)
+	if err != nil {
+		log.Err(err).Msg("Error resolving the incident of the deleted monitor")
+		return
+	}
+	if event != nil {
+		log.Info().Int64("incident_id", event.Incident.IncidentID).Msgf("Incident event: %s", event.Type)
+		go app.notify(context.Background(), notify.NewIncidentEvent(*event), log)
+	}
+}
+
+// notify sends the event using the configured notifier, if any, and the incident events to the
+// channels linked to the monitor.
+func (app *Application) notify(ctx context.Context, event notify.Event, log zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	if app.notifier != nil {
+		err := app.notifier.Notify(ctx, event)
+		if err != nil {
+			log.Err(err).Msgf("Error sending the %s notification", event.Type)
+		} else {
+			log.Info().Msgf("Sent the %s notification", event.Type)
+		}
+	}
+	if event.Type.IsIncident() && app.channels != nil {
+		app.notifyChannels(ctx, event, log)
+	}
+}
+
+// notifyChannels sends the event to all the channels of the monitor at the same time, so a slow
+// channel does not delay the others.
+func (app *Application) notifyChannels(ctx context.Context, event notify.Event, log zerolog.Logger) {
+	channels, err := app.channels.GetByMonitor(ctx, event.Monitor.MonitorID, log)
+	if err != nil {
+		log.Err(err).Msg("Error getting the channels of the monitor")
+		return
+	}
+	var wg sync.WaitGroup
+	for _, channel := range channels {
+		log := log.With().Int64("channel_id", channel.ChannelID).Logger()
+		notifier, err := app.senders.Notifier(channel)
+		if err != nil {
+			log.Err(err).Msg("Error creating the notifier of the channel")
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := notifier.Notify(ctx, event); err != nil {
+				log.Err(err).Msgf("Error sending the %s notification to the channel", event.Type)
+				return
+			}
+			log.Info().Msgf("Sent the %s notification to the channel", event.Type)
+		}()
+	}
+	wg.Wait()
+}
+
+// notifyInBackground sends the event without blocking the caller, used by the handlers so the
+// retries of the notifiers do not delay the response.
+func (app *Application) notifyInBackground(event notify.Event, log zerolog.Logger) {
+	if app.notifier == nil {
+		return
+	}
+	go app.notify(context.Background(), event, log)
+}
+
+// newCheckResult converts the result of a checker to the struct stored in the database.
+func newCheckResult(result checker.Result) data.CheckResult {
+	checkResult := data.CheckResult{
+		MonitorID:       result.MonitorID,
+		CheckedAt:       result.CheckedAt,
+		Success:         result.Success,
+		StatusCode:      result.StatusCode,
+		LatencyMs:       result.Latency.Milliseconds(),
+		ResponseSize:    result.ResponseSize,
+		ResolvedIP:      result.ResolvedIP,
+		ErrorClass:      string(result.ErrorClass),
+		Error:           result.Error,
+		CertIssuer:      result.CertIssuer,
+		FailedAssertion: result.FailedAssertion,
+		ActualValue:     result.ActualValue,
+	}
+	if !result.CertExpiresAt.IsZero() {
+		checkResult.CertExpiresAt = &result.CertExpiresAt
+	}
+	return checkResult
+}
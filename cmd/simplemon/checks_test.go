@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/incident"
+	"github.com/The-Sailors/simplemon/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_stopChecks(t *testing.T) {
+	fields := initFields()
+	monitor := data.Monitor{MonitorID: 1, FrequencyMinutes: 1}
+	monitors := data.NewMonitorModelMock()
+	monitors.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor{monitor}, nil)
+	incidents := data.NewIncidentModelMock()
+	incidents.On("GetOpen", mock.Anything, int64(1), mock.Anything).Return(&data.Incident{IncidentID: 7, MonitorID: 1}, nil)
+	incidents.On("Resolve", mock.Anything, int64(7), mock.Anything, mock.Anything).Return(&data.Incident{IncidentID: 7, MonitorID: 1, Status: data.IncidentStatusResolved}, nil)
+
+	sched := scheduler.New(monitors, func(ctx context.Context, monitor data.Monitor) {}, fields.logger, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sched.Start(ctx)
+	assert.Eventually(t, func() bool { return sched.Len() == 1 }, time.Second, 5*time.Millisecond)
+
+	app := &Application{
+		config:    fields.config,
+		logger:    fields.logger,
+		models:    monitors,
+		incidents: incidents,
+		tracker:   incident.NewTracker(incidents),
+		metrics:   newAppMetrics(),
+		scheduler: sched,
+	}
+	app.stopChecks(context.Background(), monitor, fields.logger)
+	assert.Equal(t, 0, sched.Len(), "the deleted monitor must not be checked until the next resync")
+	incidents.AssertCalled(t, "Resolve", mock.Anything, int64(7), mock.Anything, mock.Anything)
+}
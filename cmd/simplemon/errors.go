@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog"
+)
+
+// Machine readable codes of the problems returned by the API.
+const (
+	codeBadRequest       = "bad_request"
+	codeValidationFailed = "validation_failed"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeBodyTooLarge     = "body_too_large"
+	codeNotifyFailed     = "notification_failed"
+	codeInternalError    = "internal_error"
+)
+
+// problem is the body of the error responses, following RFC 7807 (application/problem+json).
+type problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"` // Problem of each invalid field
+}
+
+// errorResponse writes a problem. All the error responses of the API must be written by it.
+func (app *Application) errorResponse(w http.ResponseWriter, r *http.Request, status int, code, detail string, fields map[string]string) {
+	p := problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    fields,
+	}
+	js, err := json.Marshal(p)
+	if err != nil {
+		log := httplog.LogEntry(r.Context())
+		log.Err(err).Msg("Error marshalling the problem")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if p.RequestID != "" {
+		w.Header().Set("X-Request-Id", p.RequestID)
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	w.Write(js)
+}
+
+func (app *Application) badRequestResponse(w http.ResponseWriter, r *http.Request, detail string) {
+	app.errorResponse(w, r, http.StatusBadRequest, codeBadRequest, detail, nil)
+}
+
+func (app *Application) failedValidationResponse(w http.ResponseWriter, r *http.Request, fields map[string]string) {
+	app.errorResponse(w, r, http.StatusBadRequest, codeValidationFailed, "One or more fields are invalid", fields)
+}
+
+// unauthorizedResponse responds to a request without a valid API key or token, asking for a Bearer token.
+func (app *Application) unauthorizedResponse(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	app.errorResponse(w, r, http.StatusUnauthorized, codeUnauthorized, detail, nil)
+}
+
+// forbiddenResponse responds to a request whose user does not have the role needed by the action.
+func (app *Application) forbiddenResponse(w http.ResponseWriter, r *http.Request, detail string) {
+	app.errorResponse(w, r, http.StatusForbidden, codeForbidden, detail, nil)
+}
+
+func (app *Application) notFoundResponse(w http.ResponseWriter, r *http.Request, detail string) {
+	app.errorResponse(w, r, http.StatusNotFound, codeNotFound, detail, nil)
+}
+
+func (app *Application) methodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "The "+r.Method+" method is not supported by this resource", nil)
+}
+
+func (app *Application) conflictResponse(w http.ResponseWriter, r *http.Request, detail string) {
+	app.errorResponse(w, r, http.StatusConflict, codeConflict, detail, nil)
+}
+
+// invalidBodyResponse responds to a body that could not be read or decoded, using 413 when the
+// body is bigger than maxBodyBytes.
+func (app *Application) invalidBodyResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		detail := fmt.Sprintf("The request body must not be larger than %d bytes", maxBytesError.Limit)
+		app.errorResponse(w, r, http.StatusRequestEntityTooLarge, codeBodyTooLarge, detail, nil)
+		return
+	}
+	app.badRequestResponse(w, r, err.Error())
+}
+
+// notifyFailedResponse responds to a notification that could not be delivered to a channel.
+func (app *Application) notifyFailedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorResponse(w, r, http.StatusBadGateway, codeNotifyFailed, "The notification could not be sent: "+err.Error(), nil)
+}
+
+// serverErrorResponse logs the unexpected error and hides its details from the client.
+func (app *Application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	log := httplog.LogEntry(r.Context())
+	log.Err(err).Msg("Internal server error")
+	app.errorResponse(w, r, http.StatusInternalServerError, codeInternalError, "The server could not process the request", nil)
+}
+
+// modelErrorResponse maps the typed errors returned by the models and the checkers to a problem.
+func (app *Application) modelErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *data.ValidationError
+	var unknownTypeErr *checker.UnknownTypeError
+	var notFoundErr *data.NotFoundError
+
+	switch {
+	case errors.As(err, &validationErr):
+		app.failedValidationResponse(w, r, validationErr.Fields)
+	case errors.As(err, &unknownTypeErr):
+		app.failedValidationResponse(w, r, map[string]string{"type": unknownTypeErr.Error()})
+	case errors.As(err, &notFoundErr):
+		app.notFoundResponse(w, r, "The "+notFoundErr.Resource+" was not found")
+	case errors.Is(err, data.ErrConflict):
+		app.conflictResponse(w, r, err.Error())
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplication_modelErrorResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedFields map[string]string
+	}{
+		{
+			name:           "Test modelErrorResponse validation error",
+			err:            data.ErrInvalidHeaders,
+			expectedStatus: 400,
+			expectedCode:   codeValidationFailed,
+			expectedFields: map[string]string{"headers": "must be a JSON object with string values"},
+		},
+		{
+			name:           "Test modelErrorResponse unknown monitor type",
+			err:            &checker.UnknownTypeError{Type: "jojo", Known: []string{"http"}},
+			expectedStatus: 400,
+			expectedCode:   codeValidationFailed,
+			expectedFields: map[string]string{"type": `unknown monitor type "jojo", must be one of [http]`},
+		},
+		{
+			name:           "Test modelErrorResponse wrapped not found",
+			err:            fmt.Errorf("getting monitor: %w", data.ErrMonitorNotFound),
+			expectedStatus: 404,
+			expectedCode:   codeNotFound,
+		},
+		{
+			name:           "Test modelErrorResponse conflict",
+			err:            data.ErrUniqueConstraintViolation,
+			expectedStatus: 409,
+			expectedCode:   codeConflict,
+		},
+		{
+			name:           "Test modelErrorResponse generic error",
+			err:            errors.New("database generic error"),
+			expectedStatus: 500,
+			expectedCode:   codeInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			app := &Application{config: fields.config, logger: fields.logger}
+
+			handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				app.modelErrorResponse(w, r, tt.err)
+			}))
+			req := httptest.NewRequest("GET", "/v1/monitors/1", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			var p problem
+			err := json.Unmarshal(w.Body.Bytes(), &p)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, p.Status)
+			assert.Equal(t, tt.expectedCode, p.Code)
+			assert.Equal(t, tt.expectedFields, p.Errors)
+			assert.Equal(t, "/v1/monitors/1", p.Instance)
+			assert.NotEmpty(t, p.RequestID)
+			assert.Equal(t, p.RequestID, w.Header().Get("X-Request-Id"))
+			if tt.expectedStatus == 500 {
+				assert.NotContains(t, p.Detail, "database", "internal errors must not be leaked")
+			}
+		})
+	}
+}
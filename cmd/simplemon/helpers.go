@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// readIDParam reads the id parameter of the url and converts it to int64.
+func readIDParam(r *http.Request) (int64, error) {
+	return readInt64Param(r, "id")
+}
+
+// readInt64Param reads the named parameter of the url and converts it to int64.
+func readInt64Param(r *http.Request, name string) (int64, error) {
+	value := httprouter.ParamsFromContext(r.Context()).ByName(name)
+	if value == "" {
+		return 0, errors.New(name + " is required")
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// writeJSON writes the data as the JSON body of the response, with the given status and headers.
+func writeJSON(w http.ResponseWriter, status int, data interface{}, headers http.Header) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+	return nil
+}
+
+// maxBodyBytes is the max size of the request bodies, bigger bodies are rejected with 413.
+const maxBodyBytes = 1_048_576
+
+// readBody reads the whole body of the request, failing with *http.MaxBytesError if it is bigger
+// than maxBodyBytes.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	return io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+}
+
+// readJSON decodes the JSON body of the request into dst, see decodeJSON.
+func readJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	return decodeJSON(http.MaxBytesReader(w, r.Body, maxBodyBytes), dst)
+}
+
+// decodeJSON decodes a single JSON value into dst, rejecting the fields that dst does not have. The
+// errors of the decoder are rewritten as messages that can be returned to the client, except
+// *http.MaxBytesError that is wrapped so the caller can respond with 413.
+func decodeJSON(body io.Reader, dst interface{}) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("body contains unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes: %w", maxBytesError.Limit, err)
+		default:
+			return err
+		}
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errors.New("body must only contain a single JSON value")
+	}
+	return nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to the original JSON document: fields present
+// in the patch replace the original ones, null fields are removed, and objects are merged
+// recursively.
+func mergePatch(original, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(original, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+	return targetObject
+}
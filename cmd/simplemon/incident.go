@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/go-chi/httplog"
+)
+
+// readIncidentFilter reads the status and limit query string parameters, returning the problem
+// of each invalid parameter.
+func readIncidentFilter(r *http.Request) (data.IncidentFilter, map[string]string) {
+	filter := data.IncidentFilter{Limit: data.DefaultIncidentLimit}
+	fields := map[string]string{}
+	query := r.URL.Query()
+
+	filter.Status = query.Get("status")
+	if filter.Status != "" && filter.Status != data.IncidentStatusOpen && filter.Status != data.IncidentStatusResolved {
+		fields["status"] = "must be open or resolved"
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > data.MaxIncidentLimit {
+			fields["limit"] = "must be an integer between 1 and " + strconv.Itoa(data.MaxIncidentLimit)
+		}
+	}
+	return filter, fields
+}
+
+func (app *Application) getAllIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get All Incidents Handler")
+
+	filter, fields := readIncidentFilter(r)
+	if len(fields) > 0 {
+		log.Warn().Msg("Invalid query string parameters")
+		app.failedValidationResponse(w, r, fields)
+		return
+	}
+	filter.Owner = contextGetPrincipal(r).owner()
+	app.writeIncidents(w, r, filter)
+}
+
+func (app *Application) getMonitorIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Monitor Incidents Handler")
+
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
+	filter, fields := readIncidentFilter(r)
+	if len(fields) > 0 {
+		log.Warn().Msg("Invalid query string parameters")
+		app.failedValidationResponse(w, r, fields)
+		return
+	}
+	filter.MonitorID = monitorID
+
+	//Verify if the monitor exists
+	_, err = app.models.GetById(r.Context(), filter.MonitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.writeIncidents(w, r, filter)
+}
+
+func (app *Application) writeIncidents(w http.ResponseWriter, r *http.Request, filter data.IncidentFilter) {
+	log := httplog.LogEntry(r.Context())
+	incidents, err := app.incidents.GetAll(r.Context(), filter, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, incidents, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_getIncidentsHandlers(t *testing.T) {
+	type args struct {
+		expectedStatusCode int
+		path               string
+	}
+	type GetReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	type IncidentsReturn struct {
+		incidents []data.Incident
+		err       error
+	}
+	now := time.Now()
+	monitor := &data.Monitor{MonitorID: 1, URL: "https://www.google.com", UserEmail: "jojo@gmail.com", MonitorType: "http", Method: "GET"}
+	incidents := []data.Incident{
+		{IncidentID: 2, MonitorID: 1, Status: data.IncidentStatusOpen, FailingSince: now, OpenedAt: now, LastFailureAt: now, FailureCount: 3},
+		{IncidentID: 1, MonitorID: 1, Status: data.IncidentStatusResolved, FailingSince: now, OpenedAt: now, ResolvedAt: &now, LastFailureAt: now, FailureCount: 1},
+	}
+	tests := []struct {
+		name      string
+		fields    Fields
+		args      args
+		get       GetReturn
+		incidents IncidentsReturn
+	}{
+		{
+			name:      "Test getAllIncidentsHandler success",
+			fields:    Fields(initFields()),
+			args:      args{expectedStatusCode: 200, path: "/v1/incidents?status=open&limit=5"},
+			incidents: IncidentsReturn{incidents: incidents},
+		},
+		{
+			name:   "Test getAllIncidentsHandler invalid status",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, path: "/v1/incidents?status=closed"},
+		},
+		{
+			name:   "Test getAllIncidentsHandler invalid limit",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, path: "/v1/incidents?limit=0"},
+		},
+		{
+			name:      "Test getAllIncidentsHandler database generic error",
+			fields:    Fields(initFields()),
+			args:      args{expectedStatusCode: 500, path: "/v1/incidents"},
+			incidents: IncidentsReturn{err: errors.New("database generic error")},
+		},
+		{
+			name:      "Test getMonitorIncidentsHandler success",
+			fields:    Fields(initFields()),
+			args:      args{expectedStatusCode: 200, path: "/v1/monitors/1/incidents"},
+			get:       GetReturn{monitor: monitor},
+			incidents: IncidentsReturn{incidents: incidents},
+		},
+		{
+			name:   "Test getMonitorIncidentsHandler invalid monitor id",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, path: "/v1/monitors/invalid/incidents"},
+		},
+		{
+			name:   "Test getMonitorIncidentsHandler monitor not found",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 404, path: "/v1/monitors/1/incidents"},
+			get:    GetReturn{err: data.ErrMonitorNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitors := data.NewMonitorModelMock()
+			monitors.On("GetById", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
+			incidentModel := data.NewIncidentModelMock()
+			incidentModel.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return(tt.incidents.incidents, tt.incidents.err)
+			app := &Application{
+				config:    tt.fields.config,
+				logger:    tt.fields.logger,
+				models:    monitors,
+				incidents: incidentModel,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("GET", "/v1/incidents", app.getAllIncidentsHandler)
+			router.HandlerFunc("GET", "/v1/monitors/:id/incidents", app.getMonitorIncidentsHandler)
+
+			req := asAdmin(httptest.NewRequest("GET", tt.args.path, nil))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"os"
 	"time"
 
+	"github.com/The-Sailors/simplemon/internal/checker"
 	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/incident"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/The-Sailors/simplemon/internal/scheduler"
 	"github.com/go-chi/httplog"
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
@@ -17,14 +21,37 @@ import (
 type Config struct {
 	env      string
 	port     string
+	baseURL  string // Public URL of the API, used to link the notifications to the monitors
 	dbConfig struct {
 		postgresURL  string
 		maxOpenConns int
 		maxIdleConns int
 		maxIdleTime  string
 	}
-	logLevel  string
-	logFormat string
+	logLevel        string
+	logFormat       string
+	schedulerConfig struct {
+		resyncInterval string
+		checkTimeout   string
+	}
+	smtpConfig struct {
+		host     string
+		port     string
+		username string
+		password string
+		from     string
+		startTLS string
+	}
+	webhookConfig struct {
+		url    string
+		secret string
+	}
+	authConfig struct {
+		adminKey string // API key of the admin, that can manage the API keys and monitors of all the users
+	}
+	metricsToken     string // Bearer token required by /metrics, that is not served when it is empty
+	monitorRetention string // How long the deleted monitors can be restored before they are purged, 0 keeps them
+	migrateOnStart   string // Whether the pending migrations are applied when the API starts
 }
 
 func openDB(cfg Config, ctx context.Context) (*sql.DB, error) {
@@ -52,9 +79,22 @@ func openDB(cfg Config, ctx context.Context) (*sql.DB, error) {
 }
 
 type Application struct {
-	config Config                // All the configuration for the application
-	logger zerolog.Logger        // Generic logger for the application
-	models data.MonitorInterface // Models wraps all the application models.
+	config    Config                    // All the configuration for the application
+	logger    zerolog.Logger            // Generic logger for the application
+	models    data.MonitorInterface     // Models wraps all the application models.
+	results   data.CheckResultInterface // History of the checks executed for each monitor
+	incidents data.IncidentInterface    // Incidents opened when the monitors fail
+	checkers  *checker.Registry         // Validates and executes the monitors of each type
+	tracker   *incident.Tracker         // Opens and resolves incidents from the check results
+	notifier  notify.Notifier           // Sends the incident and monitor events, nil when notifications are disabled
+	pings     data.PingInterface        // Pings received by the heartbeat monitors
+	channels  data.ChannelInterface     // Destinations of the notifications, linked to the monitors
+	senders   *notify.Channels          // Validates the channels and sends the events to them
+	metrics   *appMetrics               // Metrics of the API and the checks exposed on /metrics
+	apiKeys   data.APIKeyInterface      // API keys that authenticate the requests
+	orgs      data.OrgInterface         // Organizations that own the monitors, with the roles of their members
+	audit     data.AuditInterface       // Log of the changes made through the API, nil when it is disabled
+	scheduler *scheduler.Scheduler      // Runs the checks of the monitors, nil when the checks are not running
 }
 
 func getEnvWithDefault(key, defaultValue string) string {
@@ -80,8 +120,46 @@ func main() {
 		},
 		env:       getEnvWithDefault("ENV", "development"),
 		port:      getEnvWithDefault("PORT", "8080"),
+		baseURL:   os.Getenv("BASE_URL"),
 		logLevel:  getEnvWithDefault("LOG_LEVEL", "info"),
 		logFormat: getEnvWithDefault("LOG_FORMAT", "text"),
+		schedulerConfig: struct {
+			resyncInterval string
+			checkTimeout   string
+		}{
+			resyncInterval: getEnvWithDefault("SCHEDULER_RESYNC_INTERVAL", "30s"),
+			checkTimeout:   getEnvWithDefault("CHECK_TIMEOUT", "30s"),
+		},
+		smtpConfig: struct {
+			host     string
+			port     string
+			username string
+			password string
+			from     string
+			startTLS string
+		}{
+			host:     os.Getenv("SMTP_HOST"),
+			port:     getEnvWithDefault("SMTP_PORT", "587"),
+			username: os.Getenv("SMTP_USERNAME"),
+			password: os.Getenv("SMTP_PASSWORD"),
+			from:     getEnvWithDefault("SMTP_FROM", "simplemon@localhost"),
+			startTLS: getEnvWithDefault("SMTP_STARTTLS", "true"),
+		},
+		webhookConfig: struct {
+			url    string
+			secret string
+		}{
+			url:    os.Getenv("WEBHOOK_URL"),
+			secret: os.Getenv("WEBHOOK_SECRET"),
+		},
+		authConfig: struct {
+			adminKey string
+		}{
+			adminKey: os.Getenv("API_ADMIN_KEY"),
+		},
+		metricsToken:     os.Getenv("METRICS_TOKEN"),
+		monitorRetention: getEnvWithDefault("MONITOR_RETENTION", "720h"),
+		migrateOnStart:   getEnvWithDefault("MIGRATE_ON_START", "true"),
 	}
 
 	//structured logs
@@ -95,11 +173,101 @@ func main() {
 		logger.Err(err).Msg("Cannot connect to database")
 		logger.Fatal()
 	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(context.Background(), db, logger, os.Args[2:], os.Stdout); err != nil {
+			logger.Fatal().Err(err).Msg("Migration failed")
+		}
+		return
+	}
+	if cfg.migrateOnStart == "true" {
+		if err := migrateOnStart(context.Background(), db, logger); err != nil {
+			logger.Fatal().Err(err).Msg("Cannot apply the pending migrations")
+		}
+	} else {
+		logger.Warn().Msg("MIGRATE_ON_START is not true, the pending migrations must be applied with simplemon migrate up")
+	}
+	checkTimeout, err := time.ParseDuration(cfg.schedulerConfig.checkTimeout)
+	if err != nil {
+		logger.Err(err).Msg("Invalid check timeout")
+		logger.Fatal()
+	}
+	incidents := data.NewIncidentModel(db)
+	pings := data.NewPingModel(db)
 	app := &Application{
-		config: cfg,
-		logger: logger,
-		models: data.NewMonitorModel(db),
+		config:    cfg,
+		logger:    logger,
+		models:    data.NewMonitorModel(db),
+		results:   data.NewCheckResultModel(db),
+		incidents: incidents,
+		checkers:  checker.NewDefaultRegistry(checkTimeout, pings),
+		tracker:   incident.NewTracker(incidents),
+		pings:     pings,
+		channels:  data.NewChannelModel(db),
+		metrics:   newAppMetrics(),
+		apiKeys:   data.NewAPIKeyModel(db),
+		orgs:      data.NewOrgModel(db),
+		audit:     data.NewAuditModel(db),
+	}
+	app.metrics.registerDBStats(db)
+	if cfg.metricsToken == "" {
+		logger.Warn().Msg("METRICS_TOKEN not set, /metrics disabled")
+	}
+	if cfg.authConfig.adminKey == "" {
+		logger.Warn().Msg("API_ADMIN_KEY not set, the API keys can only be created with another API key")
+	}
+	var notifiers notify.Multi
+	var email *notify.EmailNotifier
+	if cfg.smtpConfig.host != "" {
+		email = notify.NewEmailNotifier(notify.SMTPConfig{
+			Host:     cfg.smtpConfig.host,
+			Port:     cfg.smtpConfig.port,
+			Username: cfg.smtpConfig.username,
+			Password: cfg.smtpConfig.password,
+			From:     cfg.smtpConfig.from,
+			StartTLS: cfg.smtpConfig.startTLS == "true",
+		})
+		notifiers = append(notifiers, email)
+		logger.Info().Msgf("Email notifications enabled using %s:%s", cfg.smtpConfig.host, cfg.smtpConfig.port)
+	} else {
+		logger.Warn().Msg("SMTP_HOST not set, email notifications disabled")
 	}
+	if cfg.webhookConfig.url != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(notify.WebhookConfig{
+			URL:    cfg.webhookConfig.url,
+			Secret: cfg.webhookConfig.secret,
+		}))
+		logger.Info().Msgf("Webhook notifications enabled using %s", cfg.webhookConfig.url)
+		if cfg.webhookConfig.secret == "" {
+			logger.Warn().Msg("WEBHOOK_SECRET not set, webhooks will not be signed")
+		}
+	}
+	if len(notifiers) > 0 {
+		app.notifier = notifiers
+	}
+	app.senders = notify.NewChannels(email, cfg.baseURL)
+
+	//background checks
+	resync, err := time.ParseDuration(cfg.schedulerConfig.resyncInterval)
+	if err != nil {
+		logger.Err(err).Msg("Invalid scheduler resync interval")
+		logger.Fatal()
+	}
+	sched := scheduler.New(app.models, app.runCheck, logger, resync)
+	sched.SetInterval(app.checkers.Interval)
+	app.scheduler = sched
+	go sched.Start(context.Background())
+
+	retention, err := time.ParseDuration(cfg.monitorRetention)
+	if err != nil || retention < 0 {
+		logger.Err(err).Msg("Invalid monitor retention")
+		logger.Fatal()
+	}
+	if retention > 0 {
+		go app.purgeDeletedMonitorsEvery(context.Background(), purgeInterval, retention)
+	} else {
+		logger.Warn().Msg("MONITOR_RETENTION is 0, the deleted monitors are never purged")
+	}
+
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.port),
 		Handler:      app.routes(),
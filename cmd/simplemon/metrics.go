@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// appMetrics are the metrics exposed on /metrics. A nil *appMetrics records nothing, so the
+// handlers can be tested without it.
+type appMetrics struct {
+	registry *prometheus.Registry
+	handler  http.Handler // Serves the metrics of the registry in the Prometheus formats
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	monitorUp        *prometheus.GaugeVec
+	monitorLastCheck *prometheus.GaugeVec
+	monitorDuration  *prometheus.HistogramVec
+
+	mu sync.Mutex
+	// monitorLabels are the label values of the series of each monitor, so they can be removed
+	// when the monitor is deleted or its type or method changes.
+	monitorLabels map[int64][]string
+}
+
+func newAppMetrics() *appMetrics {
+	monitorLabels := []string{"monitor_id", "type", "method"}
+	m := &appMetrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "simplemon_http_requests_total",
+			Help: "Requests served by the API.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "simplemon_http_request_duration_seconds",
+			Help: "Time spent serving the requests of the API.",
+		}, []string{"method", "route"}),
+		monitorUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "simplemon_monitor_up",
+			Help: "Whether the last check of the monitor succeeded (1) or failed (0).",
+		}, monitorLabels),
+		monitorLastCheck: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "simplemon_monitor_last_check_timestamp_seconds",
+			Help: "Unix time of the last check of the monitor.",
+		}, monitorLabels),
+		monitorDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "simplemon_monitor_check_duration_seconds",
+			Help:    "Latency measured by the checks of the monitor.",
+			Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, monitorLabels),
+		monitorLabels: map[int64][]string{},
+	}
+	m.registry.MustRegister(
+		m.httpRequests, m.httpDuration, m.monitorUp, m.monitorLastCheck, m.monitorDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.handler = promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return m
+}
+
+// registerDBStats exposes the stats of the connection pool, as the go_sql_* metrics.
+func (m *appMetrics) registerDBStats(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, "simplemon"))
+}
+
+// instrument counts the requests served by the handler and measures their duration. The route is
+// the path pattern of the handler, so the ids in the URL do not create a series each.
+func (m *appMetrics) instrument(route string, handler http.HandlerFunc) http.HandlerFunc {
+	if m == nil {
+		return handler
+	}
+	labels := prometheus.Labels{"route": route}
+	instrumented := promhttp.InstrumentHandlerDuration(m.httpDuration.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(m.httpRequests.MustCurryWith(labels), handler))
+	return instrumented.ServeHTTP
+}
+
+// observeCheck records the result of a check of the monitor.
+func (m *appMetrics) observeCheck(monitor data.Monitor, result checker.Result) {
+	if m == nil {
+		return
+	}
+	labels := []string{strconv.FormatInt(monitor.MonitorID, 10), monitor.MonitorType, monitor.Method}
+	m.mu.Lock()
+	if previous, ok := m.monitorLabels[monitor.MonitorID]; ok && !equalLabels(previous, labels) {
+		m.deleteSeries(previous)
+	}
+	m.monitorLabels[monitor.MonitorID] = labels
+	m.mu.Unlock()
+
+	up := 0.0
+	if result.Success {
+		up = 1
+	}
+	m.monitorUp.WithLabelValues(labels...).Set(up)
+	m.monitorLastCheck.WithLabelValues(labels...).Set(float64(result.CheckedAt.UnixMilli()) / 1000)
+	m.monitorDuration.WithLabelValues(labels...).Observe(result.Latency.Seconds())
+}
+
+// forgetMonitor removes the series of the deleted monitor.
+func (m *appMetrics) forgetMonitor(monitorID int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if labels, ok := m.monitorLabels[monitorID]; ok {
+		m.deleteSeries(labels)
+		delete(m.monitorLabels, monitorID)
+	}
+}
+
+func (m *appMetrics) deleteSeries(labels []string) {
+	m.monitorUp.DeleteLabelValues(labels...)
+	m.monitorLastCheck.DeleteLabelValues(labels...)
+	m.monitorDuration.DeleteLabelValues(labels...)
+}
+
+func equalLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// metricsHandler serves the metrics to the clients that send METRICS_TOKEN as a Bearer token, like
+// Prometheus with the authorization of its scrape config.
+func (app *Application) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	expected := "Bearer " + app.config.metricsToken
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+		app.unauthorizedResponse(w, r, "The metrics require the METRICS_TOKEN as a Bearer token")
+		return
+	}
+	app.metrics.handler.ServeHTTP(w, r)
+}
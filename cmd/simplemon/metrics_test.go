@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+)
+
+func TestApplication_metricsHandler(t *testing.T) {
+	fields := initFields()
+	fields.config.metricsToken = "t0ken"
+	app := &Application{
+		config:  fields.config,
+		logger:  fields.logger,
+		metrics: newAppMetrics(),
+	}
+	router := app.routes()
+	scrape := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/v1/healthcheck", "/v1/healthcheck", "/v1/jojo"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	monitor := data.Monitor{MonitorID: 7, MonitorType: "http", Method: "GET"}
+	checkedAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	app.metrics.observeCheck(monitor, checker.Result{Success: true, CheckedAt: checkedAt, Latency: 120 * time.Millisecond})
+
+	for _, authorization := range []string{"", "Bearer jojo", "t0ken"} {
+		if w := scrape(authorization); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status code %v with authorization %q, got %v", http.StatusUnauthorized, authorization, w.Code)
+		}
+	}
+	w := scrape("Bearer t0ken")
+	if w.Code != 200 {
+		t.Fatalf("Expected status code %v, got %v", 200, w.Code)
+	}
+	for _, line := range []string{
+		`simplemon_http_requests_total{code="200",method="get",route="/v1/healthcheck"} 2`,
+		`simplemon_http_requests_total{code="404",method="get",route="unmatched"} 1`,
+		`simplemon_http_request_duration_seconds_count{method="get",route="/v1/healthcheck"} 2`,
+		`simplemon_monitor_up{method="GET",monitor_id="7",type="http"} 1`,
+		`simplemon_monitor_last_check_timestamp_seconds{method="GET",monitor_id="7",type="http"} 1.6856208e+09`,
+		`simplemon_monitor_check_duration_seconds_bucket{method="GET",monitor_id="7",type="http",le="0.25"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", line, w.Body.String())
+		}
+	}
+
+	//a monitor whose method changed or that was deleted must not keep its old series
+	monitor.Method = "HEAD"
+	app.metrics.observeCheck(monitor, checker.Result{CheckedAt: checkedAt})
+	body := scrape("Bearer t0ken").Body.String()
+	if strings.Contains(body, `method="GET",monitor_id`) || !strings.Contains(body, `simplemon_monitor_up{method="HEAD",monitor_id="7",type="http"} 0`) {
+		t.Errorf("Expected only the series of the HEAD method, got:\n%s", body)
+	}
+	app.metrics.forgetMonitor(7)
+	body = scrape("Bearer t0ken").Body.String()
+	if strings.Contains(body, `monitor_id="7"`) {
+		t.Errorf("Expected the series of the deleted monitor to be removed, got:\n%s", body)
+	}
+}
+
+func TestApplication_metricsDisabled(t *testing.T) {
+	fields := initFields()
+	app := &Application{
+		config:  fields.config,
+		logger:  fields.logger,
+		metrics: newAppMetrics(),
+	}
+	w := httptest.NewRecorder()
+	app.routes().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %v without METRICS_TOKEN, got %v", http.StatusNotFound, w.Code)
+	}
+}
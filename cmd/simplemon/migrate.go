@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/migrate"
+	"github.com/The-Sailors/simplemon/migrations"
+	"github.com/rs/zerolog"
+)
+
+const migrateUsage = `usage: simplemon migrate up|down|status [flags]
+
+  up      applies the pending migrations
+  down    reverts the last applied migrations, one by default
+  status  lists the migrations and when they were applied
+
+flags:
+`
+
+// runMigrateCommand runs the migrate subcommand, writing the status to out.
+func runMigrateCommand(ctx context.Context, db *sql.DB, logger zerolog.Logger, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	allowDestructive := flags.Bool("allow-destructive", false, "run the migrations that can lose data, like dropping a table or a column")
+	steps := flags.Int("steps", 1, "number of migrations reverted by down")
+	flags.Usage = func() {
+		fmt.Fprint(out, migrateUsage)
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		return errors.New("missing migrate action")
+	}
+	action := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return errors.New("steps must be at least 1")
+	}
+
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx, *allowDestructive)
+		if err != nil {
+			return err
+		}
+		logger.Info().Int("applied", applied).Msg("Migrations applied")
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps, *allowDestructive)
+		if err != nil {
+			return err
+		}
+		logger.Info().Int("reverted", reverted).Msg("Migrations reverted")
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		writeMigrationStatus(out, status)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+	return nil
+}
+
+func writeMigrationStatus(out io.Writer, status []migrate.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED AT\tDESTRUCTIVE")
+	for _, s := range status {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		var destructive []string
+		if s.Destructive(true) {
+			destructive = append(destructive, "up")
+		}
+		if s.Destructive(false) {
+			destructive = append(destructive, "down")
+		}
+		if len(destructive) == 0 {
+			destructive = []string{"-"}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Migration, appliedAt, strings.Join(destructive, ", "))
+	}
+	w.Flush()
+}
+
+// migrateOnStart applies the pending migrations when the API starts. The destructive migrations are
+// never applied on start, they must be applied with the migrate subcommand.
+func migrateOnStart(ctx context.Context, db *sql.DB, logger zerolog.Logger) error {
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx, false)
+	var destructive *migrate.DestructiveError
+	if errors.As(err, &destructive) {
+		return fmt.Errorf("%w, run simplemon migrate up -allow-destructive", err)
+	}
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		logger.Info().Int("applied", applied).Msg("Migrations applied")
+	}
+	return nil
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/The-Sailors/simplemon/internal/checker"
 	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/notify"
+	"github.com/The-Sailors/simplemon/internal/validator"
 	"github.com/go-chi/httplog"
-	"github.com/julienschmidt/httprouter"
 )
 
 func (app *Application) healthcheckHandler(w http.ResponseWriter, r *http.Request) {
@@ -20,158 +27,360 @@ func (app *Application) healthcheckHandler(w http.ResponseWriter, r *http.Reques
 func (app *Application) getAllMonitorsHandler(w http.ResponseWriter, r *http.Request) {
 	log := httplog.LogEntry(r.Context())
 	log.Info().Msg("Starting Get All Handler")
-	//Get all the monitors from the database
-	monitors, err := app.models.GetAll(r.Context(), log)
+
+	//read the pagination, filters and sort from the query string
+	query := r.URL.Query()
+	filter := data.MonitorFilter{
+		Owner:       contextGetPrincipal(r).owner(),
+		UserEmail:   query.Get("user_email"),
+		MonitorType: query.Get("type"),
+		Method:      query.Get("method"),
+		URLContains: query.Get("url"),
+		Sort:        query.Get("sort"),
+		Cursor:      query.Get("cursor"),
+		Limit:       data.DefaultMonitorLimit,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > data.MaxMonitorLimit {
+			log.Warn().Msgf("Invalid limit parameter: %s", limit)
+			app.failedValidationResponse(w, r, map[string]string{"limit": "must be an integer between 1 and " + strconv.Itoa(data.MaxMonitorLimit)})
+			return
+		}
+	}
+	if orgID := query.Get("org_id"); orgID != "" {
+		var err error
+		filter.OrgID, err = strconv.ParseInt(orgID, 10, 64)
+		if err != nil || filter.OrgID < 1 {
+			log.Warn().Msgf("Invalid org_id parameter: %s", orgID)
+			app.failedValidationResponse(w, r, map[string]string{"org_id": "must be a positive integer"})
+			return
+		}
+	}
+	if filter.Sort != "" && !data.ValidMonitorSort(filter.Sort) {
+		log.Warn().Msgf("Invalid sort parameter: %s", filter.Sort)
+		app.failedValidationResponse(w, r, map[string]string{"sort": "must be one of " + strings.Join(data.MonitorSortValues, ", ")})
+		return
+	}
+
+	//Get a page of monitors from the database
+	monitors, nextCursor, err := app.models.List(r.Context(), filter, log)
 	if err != nil {
-		log.Err(err).Msg("Error getting all the monitors")
-		http.Error(w, "Error getting all the monitors", http.StatusInternalServerError)
+		app.modelErrorResponse(w, r, err)
 		return
 	}
 	//Write the response
-	monitorsJson, err := json.Marshal(monitors)
+	headers := http.Header{}
+	if nextCursor != "" {
+		query.Set("cursor", nextCursor)
+		next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		headers.Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+		headers.Set("X-Next-Cursor", nextCursor)
+	}
+	err = writeJSON(w, http.StatusOK, monitors, headers)
 	if err != nil {
-		log.Err(err).Msg("Error marshalling the monitor")
-		http.Error(w, "Marshelling Error", http.StatusInternalServerError)
-		return
+		app.serverErrorResponse(w, r, err)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(monitorsJson)
 }
 
 func (app *Application) deleteMonitorHandler(w http.ResponseWriter, r *http.Request) {
 	log := httplog.LogEntry(r.Context())
 	log.Info().Msg("Starting Delete Handler")
 	//get the id from the url
-	monitorID := httprouter.ParamsFromContext(r.Context()).ByName("id")
-	if monitorID == "" {
-		log.Err(nil).Msg("Monitor id is required")
-		http.Error(w, "Monitor id is required", http.StatusBadRequest)
-		return
-	}
-	//convert the id to int
-	monitorIDInt, err := strconv.Atoi(monitorID)
+	monitorID, err := readIDParam(r)
 	if err != nil {
-		log.Err(err).Msg("Error converting id to int")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
 		return
 	}
 	//Verify if the monitor exists
-	_, err = app.models.GetById(r.Context(), int64(monitorIDInt), log)
+	monitor, err := app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
 	if err != nil {
-		if err.Error() == data.ErrMonitorNotFound.Error() {
-			log.Warn().Msg("Monitor not found")
-			http.Error(w, "Was not possible to delete the monitor because it not exists", http.StatusNotFound)
-			return
-		} else {
-			log.Err(err).Msg("Error getting the monitor")
-			http.Error(w, "Error getting the monitor", http.StatusInternalServerError)
-			return
-		}
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, monitor.OrgID, data.RoleEditor) {
+		return
 	}
 	//Delete the monitor
-	err = app.models.Delete(r.Context(), int64(monitorIDInt), log)
+	err = app.models.Delete(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
 	if err != nil {
-		log.Err(err).Msg("Error deleting the monitor")
-		http.Error(w, "Error deleting the monitor", http.StatusInternalServerError)
+		app.modelErrorResponse(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
+	app.stopChecks(r.Context(), *monitor, log)
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditMonitorDelete, TargetType: "monitor", TargetID: strconv.FormatInt(monitorID, 10), OrgID: monitor.OrgID}, monitor, nil)
+	app.notifyInBackground(notify.NewMonitorEvent(notify.EventMonitorDeleted, *monitor), log)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// restoreMonitorHandler undoes the deletion of a monitor that is not purged yet.
+func (app *Application) restoreMonitorHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Restore Handler")
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
+	monitor, err := app.models.GetDeletedById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, monitor.OrgID, data.RoleEditor) {
+		return
+	}
+	restoredMonitor, err := app.models.Restore(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditMonitorRestore, TargetType: "monitor", TargetID: strconv.FormatInt(monitorID, 10), OrgID: restoredMonitor.OrgID}, monitor, restoredMonitor)
+	app.notifyInBackground(notify.NewMonitorEvent(notify.EventMonitorRestored, *restoredMonitor), log)
+	err = writeJSON(w, http.StatusOK, restoredMonitor, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *Application) createMonitorHandler(w http.ResponseWriter, r *http.Request) {
 	log := httplog.LogEntry(r.Context())
 	var monitor data.Monitor
 
-	err := json.NewDecoder(r.Body).Decode(&monitor)
+	err := readJSON(w, r, &monitor)
 	if err != nil {
-		log.Err(err).Msg("Error decoding the request body")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	//the heartbeat period starts when the monitor is created, whatever updated_at the client sent
+	monitor.UpdatedAt = time.Now().UTC()
+	if err := app.checkers.Prepare(&monitor); err != nil {
+		app.serverErrorResponse(w, r, err)
 		return
 	}
-	//verify if the primary keys fields user email, type, url and method are not empty
-	if monitor.UserEmail == "" || monitor.MonitorType == "" || monitor.URL == "" || monitor.Method == "" {
-		log.Err(nil).Msg("User email, type, url and method are required")
-		http.Error(w, "User email, type, url and method are required", http.StatusBadRequest)
+	if err := app.setMonitorDefaults(r, &monitor); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if v := app.validateMonitor(monitor); !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid monitor")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	if !app.requireRole(w, r, monitor.OrgID, data.RoleEditor) || !app.requireMember(w, r, monitor.OrgID, monitor.UserEmail) {
 		return
 	}
 	//Create the monitor in the database
 	createdMonitor, err := app.models.Create(r.Context(), monitor, log)
 	if err != nil {
-
-		//verify if the error is a unique constraint violation
-		if err.Error() == data.ErrUniqueConstraintViolation.Error() {
-			log.Warn().Msg("Monitor already exists")
-			http.Error(w, "Monitor already exists", http.StatusConflict)
-			return
-		} else {
-			log.Err(err).Msg("Error creating the monitor")
-			http.Error(w, "Error creating the monitor", http.StatusInternalServerError)
-		}
+		app.modelErrorResponse(w, r, err)
 		return
 	}
-	createdMonitorJson, err := json.Marshal(createdMonitor)
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditMonitorCreate, TargetType: "monitor", TargetID: strconv.FormatInt(createdMonitor.MonitorID, 10), OrgID: createdMonitor.OrgID}, nil, createdMonitor)
+	app.notifyInBackground(notify.NewMonitorEvent(notify.EventMonitorCreated, *createdMonitor), log)
+	err = writeJSON(w, http.StatusCreated, createdMonitor, nil)
 	if err != nil {
-		log.Err(err).Msg("Error marshalling the monitor")
-		http.Error(w, "Marshelling Error", http.StatusInternalServerError)
-		return
+		app.serverErrorResponse(w, r, err)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	w.Write(createdMonitorJson)
 }
 
 func (app *Application) getMonitorHandler(w http.ResponseWriter, r *http.Request) {
 	log := httplog.LogEntry(r.Context())
 	log.Info().Msg("Starting Get Monitor Handler")
-	params := httprouter.ParamsFromContext(r.Context())
 
-	monitorID := params.ByName("id")
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
 
-	if monitorID == "" {
-		log.Err(nil).Msg("Monitor id is required")
-		http.Error(w, "Monitor id is required", http.StatusBadRequest)
+	//Get the monitor from the database
+	monitor, err := app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
 		return
 	}
-	//convert string to int64
-	monitorIDInt, err := strconv.Atoi(monitorID)
+	err = writeJSON(w, http.StatusOK, monitor, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// validateMonitor verifies the fields shared by all the monitor types and the fields specific to
+// its type using the checkers registry, collecting the errors of all the fields.
+func (app *Application) validateMonitor(monitor data.Monitor) *validator.Validator {
+	v := validator.New()
+	data.ValidateMonitor(v, monitor)
+	if monitor.MonitorType == "" {
+		return v
+	}
+	var validationErr *data.ValidationError
+	var unknownTypeErr *checker.UnknownTypeError
+	err := app.checkers.Validate(monitor)
+	switch {
+	case errors.As(err, &validationErr):
+		v.Merge(validationErr.Fields)
+	case errors.As(err, &unknownTypeErr):
+		v.AddError("type", unknownTypeErr.Error())
+	case err != nil:
+		v.AddError("type", err.Error())
+	}
+	return v
+}
+
+// setMonitorDefaults sets the fields of the monitor that the user of the request can omit: the
+// user_email is the email of the user, and the org_id is its organization when it has only one.
+func (app *Application) setMonitorDefaults(r *http.Request, monitor *data.Monitor) error {
+	p := contextGetPrincipal(r)
+	if monitor.UserEmail == "" {
+		monitor.UserEmail = p.email
+	}
+	if monitor.OrgID != 0 {
+		return nil
+	}
+	var err error
+	monitor.OrgID, err = app.defaultOrgID(r)
+	return err
+}
+
+// defaultOrgID returns the organization of the user of the request when it has only one, 0 otherwise
+// and for the admin.
+func (app *Application) defaultOrgID(r *http.Request) (int64, error) {
+	log := httplog.LogEntry(r.Context())
+	p := contextGetPrincipal(r)
+	if p.admin {
+		return 0, nil
+	}
+	orgs, err := app.orgs.GetAll(r.Context(), p.owner(), log)
 	if err != nil {
-		log.Err(err).Msgf("Error converting the monitor id: %s to int", monitorID)
+		return 0, err
+	}
+	if len(orgs) == 1 {
+		return orgs[0].OrgID, nil
+	}
+	return 0, nil
+}
 
-		http.Error(w, "Invalid integer parameters", http.StatusBadRequest)
+// updateMonitorHandler replaces all the fields of the monitor (PUT).
+func (app *Application) updateMonitorHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Update Monitor Handler")
+
+	monitorID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
+	var monitor data.Monitor
+	err = readJSON(w, r, &monitor)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
 		return
 	}
+	current, err := app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	if !app.requireRole(w, r, current.OrgID, data.RoleEditor) {
+		return
+	}
+	//the monitor stays in its organization when the org_id is omitted
+	if monitor.OrgID == 0 {
+		monitor.OrgID = current.OrgID
+	}
+	app.saveMonitor(w, r, *current, monitor)
+}
 
-	//Get the monitor from the database
-	monitor, err := app.models.GetById(r.Context(), int64(monitorIDInt), log)
+// patchMonitorHandler applies a JSON merge patch (RFC 7386) to the monitor (PATCH).
+func (app *Application) patchMonitorHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Patch Monitor Handler")
+
+	monitorID, err := readIDParam(r)
 	if err != nil {
-		if err.Error() == data.ErrMonitorNotFound.Error() {
-			log.Warn().Msg("Monitor not found")
-			http.Error(w, "Monitor not found", http.StatusNotFound)
-			return
-		} else {
+		log.Warn().Err(err).Msg("Error reading the monitor id")
+		app.badRequestResponse(w, r, "The monitor id must be an integer")
+		return
+	}
+	patch, err := readBody(w, r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
 
-			log.Err(err).Msg("Error getting the monitor")
-			http.Error(w, "Error getting the monitor", http.StatusInternalServerError)
-			return
-		}
+	current, err := app.models.GetById(r.Context(), monitorID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
 	}
-	monitorJson, err := json.Marshal(monitor)
+	if !app.requireRole(w, r, current.OrgID, data.RoleEditor) {
+		return
+	}
+	currentJson, err := json.Marshal(current)
 	if err != nil {
-		log.Err(err).Msg("Error marshalling the monitor")
-		http.Error(w, "Marshelling Error", http.StatusInternalServerError)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(monitorJson)
+	patchedJson, err := mergePatch(currentJson, patch)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error applying the merge patch")
+		app.badRequestResponse(w, r, "The request body must be a JSON merge patch")
+		return
+	}
+	var monitor data.Monitor
+	err = decodeJSON(bytes.NewReader(patchedJson), &monitor)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the patched monitor")
+		app.badRequestResponse(w, r, err.Error())
+		return
+	}
+	app.saveMonitor(w, r, *current, monitor)
 }
 
-// func (app *Application) updateMonitorHandler(w http.ResponseWriter, r *http.Request) {
-// 	fmt.Fprintln(w, "Update Monitor")
-// }
+// saveMonitor validates and stores the new version of the current monitor, writing it in the
+// response. The id and the update time are always set by the server. Moving the monitor to another
+// organization requires the editor role in both organizations.
+func (app *Application) saveMonitor(w http.ResponseWriter, r *http.Request, current data.Monitor, monitor data.Monitor) {
+	log := httplog.LogEntry(r.Context())
+	if err := app.checkers.Prepare(&monitor); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err := app.setMonitorDefaults(r, &monitor); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if v := app.validateMonitor(monitor); !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid monitor")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	if monitor.OrgID != current.OrgID && !app.requireRole(w, r, monitor.OrgID, data.RoleEditor) {
+		return
+	}
+	if (monitor.UserEmail != current.UserEmail || monitor.OrgID != current.OrgID) && !app.requireMember(w, r, monitor.OrgID, monitor.UserEmail) {
+		return
+	}
+	monitor.MonitorID = current.MonitorID
+	monitor.UpdatedAt = time.Now().UTC()
 
-// func (app *Application) deleteMonitorHandler(w http.ResponseWriter, r *http.Request) {
-// 	fmt.Fprintln(w, "Delete Monitor")
-// }
+	updatedMonitor, err := app.models.Update(r.Context(), monitor, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditMonitorUpdate, TargetType: "monitor", TargetID: strconv.FormatInt(current.MonitorID, 10), OrgID: updatedMonitor.OrgID}, current, updatedMonitor)
+	err = writeJSON(w, http.StatusOK, updatedMonitor, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/The-Sailors/simplemon/internal/checker"
 	"github.com/The-Sailors/simplemon/internal/data"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/zerolog"
@@ -16,11 +19,15 @@ import (
 )
 
 type Fields struct {
-	config Config
-	logger zerolog.Logger
+	config   Config
+	logger   zerolog.Logger
+	checkers *checker.Registry
+	orgs     *data.OrgModelMock // Every user is a member of every organization
 }
 
 func initFields() Fields {
+	members := data.NewOrgModelMock()
+	members.On("GetRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(data.RoleEditor, nil)
 
 	cfg := Config{
 		env:       "dev",
@@ -28,8 +35,10 @@ func initFields() Fields {
 		logFormat: "text",
 	}
 	return Fields{
-		config: cfg,
-		logger: setupLog(cfg),
+		config:   cfg,
+		logger:   setupLog(cfg),
+		checkers: checker.NewDefaultRegistry(time.Second, data.NewPingModelMock()),
+		orgs:     members,
 	}
 }
 
@@ -55,9 +64,10 @@ func TestApplication_createMonitorHandler(t *testing.T) {
 			fields: Fields(initFields()),
 			args: args{
 				monitor: &data.Monitor{
+					OrgID:            1,
 					URL:              "https://www.google.com",
 					UserEmail:        "jojo@gmail.com",
-					MonitorType:      "jojo",
+					MonitorType:      "http",
 					Method:           "GET",
 					UpdatedAt:        time.Now(),
 					Body:             "",
@@ -75,7 +85,7 @@ func TestApplication_createMonitorHandler(t *testing.T) {
 					MonitorID:        1,
 					URL:              "https://www.google.com",
 					UserEmail:        "jojo@gmail.com",
-					MonitorType:      "jojo",
+					MonitorType:      "http",
 					Method:           "GET",
 					UpdatedAt:        time.Now(),
 					Body:             "",
@@ -93,9 +103,10 @@ func TestApplication_createMonitorHandler(t *testing.T) {
 			fields: Fields(initFields()),
 			args: args{
 				monitor: &data.Monitor{
+					OrgID:            1,
 					URL:              "https://www.google.com",
 					UserEmail:        "jojo@gmail.com",
-					MonitorType:      "jojo",
+					MonitorType:      "http",
 					Method:           "GET",
 					UpdatedAt:        time.Now(),
 					Body:             "",
@@ -135,15 +146,126 @@ func TestApplication_createMonitorHandler(t *testing.T) {
 			},
 		},
 		{
-			name:   "Test createMonitorHandler generic database error",
+			name:   "Test createMonitorHandler unknown monitor type",
 			fields: Fields(initFields()),
 			args: args{
 				monitor: &data.Monitor{
+					OrgID:            1,
 					URL:              "https://www.google.com",
 					UserEmail:        "jojo@gmail.com",
 					MonitorType:      "jojo",
 					Method:           "GET",
 					UpdatedAt:        time.Now(),
+					FrequencyMinutes: 1,
+					ThresholdMinutes: 1,
+				},
+				expectedStatusCode: 400,
+				method:             "POST",
+			},
+			create: CreateReturn{
+				monitor: nil,
+				err:     nil,
+			},
+		},
+		{
+			name:   "Test createMonitorHandler invalid config for the monitor type",
+			fields: Fields(initFields()),
+			args: args{
+				monitor: &data.Monitor{
+					OrgID:            1,
+					URL:              "https://www.google.com",
+					UserEmail:        "jojo@gmail.com",
+					MonitorType:      "http",
+					Method:           "GET",
+					UpdatedAt:        time.Now(),
+					FrequencyMinutes: 1,
+					ThresholdMinutes: 1,
+					Config:           []byte(`{"timeout": 10}`),
+				},
+				expectedStatusCode: 400,
+				method:             "POST",
+			},
+			create: CreateReturn{
+				monitor: nil,
+				err:     nil,
+			},
+		},
+		{
+			name:   "Test createMonitorHandler invalid headers encoding",
+			fields: Fields(initFields()),
+			args: args{
+				monitor: &data.Monitor{
+					OrgID:            1,
+					URL:              "https://www.google.com",
+					UserEmail:        "jojo@gmail.com",
+					MonitorType:      "http",
+					Method:           "GET",
+					UpdatedAt:        time.Now(),
+					Headers:          "Authorization: Bearer jojo",
+					FrequencyMinutes: 1,
+					ThresholdMinutes: 1,
+				},
+				expectedStatusCode: 400,
+				method:             "POST",
+			},
+			create: CreateReturn{
+				monitor: nil,
+				err:     nil,
+			},
+		},
+		{
+			name:   "Test createMonitorHandler invalid parameters encoding",
+			fields: Fields(initFields()),
+			args: args{
+				monitor: &data.Monitor{
+					OrgID:            1,
+					URL:              "https://www.google.com",
+					UserEmail:        "jojo@gmail.com",
+					MonitorType:      "http",
+					Method:           "GET",
+					UpdatedAt:        time.Now(),
+					Parameters:       `{"page": 1}`,
+					FrequencyMinutes: 1,
+					ThresholdMinutes: 1,
+				},
+				expectedStatusCode: 400,
+				method:             "POST",
+			},
+			create: CreateReturn{
+				monitor: nil,
+				err:     nil,
+			},
+		},
+		{
+			name:   "Test createMonitorHandler heartbeat monitor gets a ping url",
+			fields: Fields(initFields()),
+			args: args{
+				monitor: &data.Monitor{
+					OrgID:            1,
+					UserEmail:        "jojo@gmail.com",
+					MonitorType:      "heartbeat",
+					FrequencyMinutes: 1440,
+					ThresholdMinutes: 60,
+				},
+				expectedStatusCode: 201,
+				method:             "POST",
+			},
+			create: CreateReturn{
+				monitor: &data.Monitor{MonitorID: 1, MonitorType: "heartbeat", URL: "/v1/ping/0123456789abcdefghij_-"},
+				err:     nil,
+			},
+		},
+		{
+			name:   "Test createMonitorHandler generic database error",
+			fields: Fields(initFields()),
+			args: args{
+				monitor: &data.Monitor{
+					OrgID:            1,
+					URL:              "https://www.google.com",
+					UserEmail:        "jojo@gmail.com",
+					MonitorType:      "http",
+					Method:           "GET",
+					UpdatedAt:        time.Now(),
 					Body:             "",
 					Headers:          "",
 					Parameters:       "",
@@ -165,16 +287,18 @@ func TestApplication_createMonitorHandler(t *testing.T) {
 			testObj := data.NewMonitorModelMock()
 			testObj.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(tt.create.monitor, tt.create.err)
 			app := &Application{
-				config: tt.fields.config,
-				logger: tt.fields.logger,
-				models: testObj,
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				models:   testObj,
+				checkers: tt.fields.checkers,
+				orgs:     tt.fields.orgs,
 			}
 			monitorJson, err := json.Marshal(tt.args.monitor)
 			if err != nil {
 				t.Errorf("Error marshalling monitor: %v", err)
 			}
 			monitorString := string(monitorJson)
-			req := httptest.NewRequest(tt.args.method, "/v1/monitors", strings.NewReader(monitorString))
+			req := asAdmin(httptest.NewRequest(tt.args.method, "/v1/monitors", strings.NewReader(monitorString)))
 			w := httptest.NewRecorder()
 
 			handler := http.HandlerFunc(app.createMonitorHandler)
@@ -187,6 +311,144 @@ func TestApplication_createMonitorHandler(t *testing.T) {
 	}
 }
 
+// TestApplication_createHeartbeatMonitor verifies that a heartbeat monitor created without
+// updated_at is not late on its first check, before any ping could be received.
+func TestApplication_createHeartbeatMonitor(t *testing.T) {
+	fields := initFields()
+	var created data.Monitor
+	testObj := data.NewMonitorModelMock()
+	testObj.On("Create", mock.Anything, mock.MatchedBy(func(monitor data.Monitor) bool {
+		created = monitor
+		return true
+	}), mock.Anything).Return(&data.Monitor{MonitorID: 1}, nil)
+	app := &Application{
+		config:   fields.config,
+		logger:   fields.logger,
+		models:   testObj,
+		checkers: fields.checkers,
+		orgs:     fields.orgs,
+	}
+	body := `{"user_email":"jojo@gmail.com","org_id":1,"type":"heartbeat","frequency_minutes":60,"threshold_minutes":60}`
+	req := asUser(httptest.NewRequest("POST", "/v1/monitors", strings.NewReader(body)), "jojo@gmail.com")
+	w := httptest.NewRecorder()
+	http.HandlerFunc(app.createMonitorHandler).ServeHTTP(w, req)
+	if w.Code != 201 {
+		t.Fatalf("Expected status code %v, got %v: %s", 201, w.Code, w.Body.String())
+	}
+
+	pings := data.NewPingModelMock()
+	pings.On("GetLast", mock.Anything, int64(1), mock.Anything).Return(&data.LastPings{}, nil)
+	created.MonitorID = 1
+	result := checker.NewHeartbeatChecker(pings).Check(context.Background(), created)
+	if !result.Success {
+		t.Errorf("Expected the new monitor to be on time, got %v: %s", result.ErrorClass, result.Error)
+	}
+}
+
+func TestApplication_createMonitorHandlerValidation(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		expectedStatusCode int
+		expectedErrors     map[string]string
+	}{
+		{
+			name:               "Test createMonitorHandler reports all the invalid fields",
+			body:               `{"org_id":1,"user_email":"jojo","type":"http","url":"ftp://www.google.com","method":"FETCH","frequency_minutes":0,"threshold_minutes":-1}`,
+			expectedStatusCode: 400,
+			expectedErrors: map[string]string{
+				"user_email":        "must be a valid email address",
+				"url":               "must be an absolute http or https url",
+				"method":            "must be one of GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS",
+				"frequency_minutes": "must be between 1 and 1440",
+				"threshold_minutes": "must be between 0 and 10080",
+			},
+		},
+		{
+			name:               "Test createMonitorHandler required fields",
+			body:               `{"frequency_minutes":1}`,
+			expectedStatusCode: 400,
+			expectedErrors: map[string]string{
+				"org_id":     "is required",
+				"user_email": "is required",
+				"type":       "is required",
+				"url":        "is required",
+			},
+		},
+		{
+			name:               "Test createMonitorHandler required fields of the http type",
+			body:               `{"type":"http","frequency_minutes":1}`,
+			expectedStatusCode: 400,
+			expectedErrors: map[string]string{
+				"org_id":     "is required",
+				"user_email": "is required",
+				"url":        "is required",
+				"method":     "is required",
+			},
+		},
+		{
+			name:               "Test createMonitorHandler fields not supported by the tcp type",
+			body:               `{"org_id":1,"user_email":"jojo@gmail.com","type":"tcp","url":"db.internal","method":"GET","frequency_minutes":1}`,
+			expectedStatusCode: 400,
+			expectedErrors: map[string]string{
+				"url":    "must be a host:port address",
+				"method": "is not supported by tcp monitors",
+			},
+		},
+		{
+			name:               "Test createMonitorHandler unknown field",
+			body:               `{"user_email":"jojo@gmail.com","type":"http","url":"https://www.google.com","method":"GET","frequency_minutes":1,"interval":5}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test createMonitorHandler incorrect JSON type",
+			body:               `{"user_email":"jojo@gmail.com","frequency_minutes":"1"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test createMonitorHandler more than one JSON value",
+			body:               `{"user_email":"jojo@gmail.com"}{}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test createMonitorHandler body too large",
+			body:               `{"user_email":"jojo@gmail.com","body":"` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			expectedStatusCode: 413,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			testObj := data.NewMonitorModelMock()
+			app := &Application{
+				config:   fields.config,
+				logger:   fields.logger,
+				models:   testObj,
+				checkers: fields.checkers,
+				orgs:     fields.orgs,
+			}
+			req := asAdmin(httptest.NewRequest("POST", "/v1/monitors", strings.NewReader(tt.body)))
+			w := httptest.NewRecorder()
+
+			handler := http.HandlerFunc(app.createMonitorHandler)
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.expectedStatusCode, w.Code)
+			}
+			if tt.expectedErrors != nil {
+				var p problem
+				if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+					t.Fatalf("Error unmarshalling problem: %v", err)
+				}
+				if !reflect.DeepEqual(p.Errors, tt.expectedErrors) {
+					t.Errorf("Expected errors %v, got %v", tt.expectedErrors, p.Errors)
+				}
+			}
+			testObj.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestApplication_getMonitorHandler(t *testing.T) {
 
 	type args struct {
@@ -218,7 +480,7 @@ func TestApplication_getMonitorHandler(t *testing.T) {
 					MonitorID:   1,
 					URL:         "https://www.google.com",
 					UserEmail:   "jojo@gmail.com",
-					MonitorType: "jojo",
+					MonitorType: "http",
 					Method:      "GET",
 					UpdatedAt:   time.Now(),
 					Body:        "",
@@ -272,7 +534,7 @@ func TestApplication_getMonitorHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testObj := data.NewMonitorModelMock()
-			testObj.On("GetById", mock.Anything, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
+			testObj.On("GetById", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
 			app := &Application{
 				config: tt.fields.config,
 				logger: tt.fields.logger,
@@ -282,7 +544,7 @@ func TestApplication_getMonitorHandler(t *testing.T) {
 			router := httprouter.New()
 			router.HandlerFunc(tt.args.method, "/v1/monitors/:id", app.getMonitorHandler)
 
-			req := httptest.NewRequest(tt.args.method, "/v1/monitors/"+tt.args.monitor_id, nil)
+			req := asAdmin(httptest.NewRequest(tt.args.method, "/v1/monitors/"+tt.args.monitor_id, nil))
 			w := httptest.NewRecorder()
 			router.ServeHTTP(w, req)
 			// handler := http.HandlerFunc(app.getMonitorHandler)
@@ -329,7 +591,7 @@ func TestApplication_deleteMonitorHandler(t *testing.T) {
 				err: nil,
 			},
 			get: GetReturn{
-				monitor: nil,
+				monitor: &data.Monitor{MonitorID: 1},
 				err:     nil,
 			},
 		},
@@ -377,7 +639,7 @@ func TestApplication_deleteMonitorHandler(t *testing.T) {
 				err: errors.New("database generic error"),
 			},
 			get: GetReturn{
-				monitor: nil,
+				monitor: &data.Monitor{MonitorID: 1},
 				err:     nil,
 			},
 		},
@@ -386,8 +648,8 @@ func TestApplication_deleteMonitorHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			testObj := data.NewMonitorModelMock()
 			//The delete handler calls the get function before deleting the verify if the monitor exists
-			testObj.On("GetById", mock.Anything, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
-			testObj.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(tt.delete.err)
+			testObj.On("GetById", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
+			testObj.On("Delete", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.delete.err)
 			app := &Application{
 				config: tt.fields.config,
 				logger: tt.fields.logger,
@@ -397,7 +659,7 @@ func TestApplication_deleteMonitorHandler(t *testing.T) {
 			router := httprouter.New()
 			router.HandlerFunc(tt.args.method, "/v1/monitors/:id", app.deleteMonitorHandler)
 
-			req := httptest.NewRequest(tt.args.method, "/v1/monitors/"+tt.args.monitor_id, nil)
+			req := asAdmin(httptest.NewRequest(tt.args.method, "/v1/monitors/"+tt.args.monitor_id, nil))
 			w := httptest.NewRecorder()
 			router.ServeHTTP(w, req)
 
@@ -410,14 +672,99 @@ func TestApplication_deleteMonitorHandler(t *testing.T) {
 
 }
 
+func TestApplication_restoreMonitorHandler(t *testing.T) {
+	deletedAt := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name               string
+		user               string
+		monitorID          string
+		getErr             error
+		restoreErr         error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Test restoreMonitorHandler restored successfully",
+			user:               "editor@gmail.com",
+			monitorID:          "1",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Test restoreMonitorHandler monitor not deleted or purged",
+			user:               "editor@gmail.com",
+			monitorID:          "1",
+			getErr:             data.ErrMonitorNotFound,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Test restoreMonitorHandler viewer can not restore",
+			user:               "viewer@gmail.com",
+			monitorID:          "1",
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Test restoreMonitorHandler same monitor created again",
+			user:               "editor@gmail.com",
+			monitorID:          "1",
+			restoreErr:         data.ErrUniqueConstraintViolation,
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Test restoreMonitorHandler invalid monitor id",
+			user:               "editor@gmail.com",
+			monitorID:          "invalid",
+			expectedStatusCode: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			monitors := data.NewMonitorModelMock()
+			if tt.getErr != nil {
+				monitors.On("GetDeletedById", mock.Anything, int64(1), tt.user, mock.Anything).Return((*data.Monitor)(nil), tt.getErr)
+			} else {
+				monitors.On("GetDeletedById", mock.Anything, int64(1), tt.user, mock.Anything).Return(&data.Monitor{MonitorID: 1, OrgID: 1, DeletedAt: &deletedAt}, nil)
+			}
+			if tt.restoreErr != nil {
+				monitors.On("Restore", mock.Anything, int64(1), tt.user, mock.Anything).Return((*data.Monitor)(nil), tt.restoreErr)
+			} else {
+				monitors.On("Restore", mock.Anything, int64(1), tt.user, mock.Anything).Return(&data.Monitor{MonitorID: 1, OrgID: 1}, nil)
+			}
+			orgs := data.NewOrgModelMock()
+			orgs.On("GetRole", mock.Anything, int64(1), "viewer@gmail.com", mock.Anything).Return(data.RoleViewer, nil)
+			orgs.On("GetRole", mock.Anything, int64(1), "editor@gmail.com", mock.Anything).Return(data.RoleEditor, nil)
+			app := &Application{
+				config: fields.config,
+				logger: fields.logger,
+				models: monitors,
+				orgs:   orgs,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("POST", "/v1/monitors/:id/restore", app.restoreMonitorHandler)
+
+			req := asUser(httptest.NewRequest("POST", "/v1/monitors/"+tt.monitorID+"/restore", nil), tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if tt.expectedStatusCode != 200 && tt.expectedStatusCode != 409 {
+				monitors.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
 func TestApplication_getAllMonitorsHandler(t *testing.T) {
 	type args struct {
 		expectedStatusCode int
 		method             string
+		query              string
+		expectedLink       string
 	}
 	type GetAllReturn struct {
-		monitors []data.Monitor
-		err      error
+		monitors   []data.Monitor
+		nextCursor string
+		err        error
 	}
 	tests := []struct {
 		name   string
@@ -438,7 +785,7 @@ func TestApplication_getAllMonitorsHandler(t *testing.T) {
 						MonitorID:   1,
 						URL:         "https://www.google.com",
 						UserEmail:   "jojo@gmail.com",
-						MonitorType: "jojo",
+						MonitorType: "http",
 						Method:      "GET",
 						UpdatedAt:   time.Now(),
 						Body:        "",
@@ -462,6 +809,59 @@ func TestApplication_getAllMonitorsHandler(t *testing.T) {
 				err: nil,
 			},
 		},
+		{
+			name:   "Test getAllMonitorsHandler with filters and next page",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 200,
+				method:             "GET",
+				query:              "?user_email=jojo%40gmail.com&url=google&sort=-updated_at&limit=1",
+				expectedLink:       `</v1/monitors?cursor=next&limit=1&sort=-updated_at&url=google&user_email=jojo%40gmail.com>; rel="next"`,
+			},
+			getAll: GetAllReturn{
+				monitors: []data.Monitor{
+					{
+						MonitorID:   1,
+						URL:         "https://www.google.com",
+						UserEmail:   "jojo@gmail.com",
+						MonitorType: "http",
+						Method:      "GET",
+						UpdatedAt:   time.Now(),
+					},
+				},
+				nextCursor: "next",
+			},
+		},
+		{
+			name:   "Test getAllMonitorsHandler invalid limit",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 400,
+				method:             "GET",
+				query:              "?limit=0",
+			},
+		},
+		{
+			name:   "Test getAllMonitorsHandler invalid sort",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 400,
+				method:             "GET",
+				query:              "?sort=url",
+			},
+		},
+		{
+			name:   "Test getAllMonitorsHandler invalid cursor",
+			fields: Fields(initFields()),
+			args: args{
+				expectedStatusCode: 400,
+				method:             "GET",
+				query:              "?cursor=invalid",
+			},
+			getAll: GetAllReturn{
+				err: data.ErrInvalidCursor,
+			},
+		},
 		{
 			name:   "Test getAllMonitorsHandler database generic error",
 			fields: Fields(initFields()),
@@ -478,7 +878,7 @@ func TestApplication_getAllMonitorsHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testObj := data.NewMonitorModelMock()
-			testObj.On("GetAll", mock.Anything, mock.Anything).Return(tt.getAll.monitors, tt.getAll.err)
+			testObj.On("List", mock.Anything, mock.Anything, mock.Anything).Return(tt.getAll.monitors, tt.getAll.nextCursor, tt.getAll.err)
 			app := &Application{
 				config: tt.fields.config,
 				logger: tt.fields.logger,
@@ -486,14 +886,218 @@ func TestApplication_getAllMonitorsHandler(t *testing.T) {
 			}
 			//How to test query params: https://stackoverflow.com/questions/43502432/how-to-write-test-with-httprouter
 
-			req := httptest.NewRequest(tt.args.method, "/v1/monitors", nil)
+			req := asAdmin(httptest.NewRequest(tt.args.method, "/v1/monitors"+tt.args.query, nil))
 			w := httptest.NewRecorder()
 			handler := http.HandlerFunc(app.getAllMonitorsHandler)
 			handler.ServeHTTP(w, req)
 			if w.Code != tt.args.expectedStatusCode {
 				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
 			}
+			if link := w.Header().Get("Link"); link != tt.args.expectedLink {
+				t.Errorf("Expected Link header %v, got %v", tt.args.expectedLink, link)
+			}
+
+		})
+	}
+}
 
+func TestApplication_updateMonitorHandler(t *testing.T) {
+	type args struct {
+		expectedStatusCode int
+		monitor_id         string
+		body               string
+	}
+	type GetReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	type UpdateReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	validBody := `{"user_email":"jojo@gmail.com","type":"http","url":"https://www.google.com","method":"GET","frequency_minutes":5,"threshold_minutes":10}`
+	current := &data.Monitor{MonitorID: 1, OrgID: 1}
+	tests := []struct {
+		name   string
+		fields Fields
+		args   args
+		get    GetReturn
+		update UpdateReturn
+	}{
+		{
+			name:   "Test updateMonitorHandler success",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, monitor_id: "1", body: validBody},
+			get:    GetReturn{monitor: current},
+			update: UpdateReturn{monitor: &data.Monitor{MonitorID: 1, FrequencyMinutes: 5}},
+		},
+		{
+			name:   "Test updateMonitorHandler invalid monitor id",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, monitor_id: "invalid", body: validBody},
+		},
+		{
+			name:   "Test updateMonitorHandler missing principal fields",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, monitor_id: "1", body: `{"frequency_minutes":5}`},
+			get:    GetReturn{monitor: current},
+		},
+		{
+			name:   "Test updateMonitorHandler monitor not found",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 404, monitor_id: "1", body: validBody},
+			get:    GetReturn{err: data.ErrMonitorNotFound},
+		},
+		{
+			name:   "Test updateMonitorHandler conflict with another monitor",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 409, monitor_id: "1", body: validBody},
+			get:    GetReturn{monitor: current},
+			update: UpdateReturn{err: data.ErrUniqueConstraintViolation},
+		},
+		{
+			name:   "Test updateMonitorHandler database generic error",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 500, monitor_id: "1", body: validBody},
+			get:    GetReturn{monitor: current},
+			update: UpdateReturn{err: errors.New("database generic error")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testObj := data.NewMonitorModelMock()
+			testObj.On("GetById", mock.Anything, int64(1), "", mock.Anything).Return(tt.get.monitor, tt.get.err)
+			//the monitor stays in its organization, since the body has no org_id
+			testObj.On("Update", mock.Anything, mock.MatchedBy(func(m data.Monitor) bool {
+				return m.MonitorID == 1 && m.OrgID == 1 && !m.UpdatedAt.IsZero()
+			}), "", mock.Anything).Return(tt.update.monitor, tt.update.err)
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				models:   testObj,
+				checkers: tt.fields.checkers,
+				orgs:     tt.fields.orgs,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("PUT", "/v1/monitors/:id", app.updateMonitorHandler)
+
+			req := asAdmin(httptest.NewRequest("PUT", "/v1/monitors/"+tt.args.monitor_id, strings.NewReader(tt.args.body)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestApplication_patchMonitorHandler(t *testing.T) {
+	type args struct {
+		expectedStatusCode int
+		monitor_id         string
+		body               string
+	}
+	type GetReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	type UpdateReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	current := &data.Monitor{
+		MonitorID:        1,
+		OrgID:            1,
+		URL:              "https://www.google.com",
+		UserEmail:        "jojo@gmail.com",
+		MonitorType:      "http",
+		Method:           "GET",
+		Description:      "google",
+		FrequencyMinutes: 1,
+		ThresholdMinutes: 1,
+	}
+	tests := []struct {
+		name     string
+		fields   Fields
+		args     args
+		get      GetReturn
+		update   UpdateReturn
+		expected func(m data.Monitor) bool // verify the monitor sent to the Update method
+	}{
+		{
+			name:   "Test patchMonitorHandler success",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, monitor_id: "1", body: `{"frequency_minutes":5,"description":null,"monitor_id":42}`},
+			get:    GetReturn{monitor: current},
+			update: UpdateReturn{monitor: current},
+			expected: func(m data.Monitor) bool {
+				return m.MonitorID == 1 && m.FrequencyMinutes == 5 && m.Description == "" &&
+					m.URL == current.URL && m.ThresholdMinutes == 1 && !m.UpdatedAt.IsZero()
+			},
+		},
+		{
+			name:   "Test patchMonitorHandler invalid patch",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, monitor_id: "1", body: `{"frequency_minutes":`},
+			get:    GetReturn{monitor: current},
+		},
+		{
+			name:   "Test patchMonitorHandler unknown field",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, monitor_id: "1", body: `{"interval":5}`},
+			get:    GetReturn{monitor: current},
+		},
+		{
+			name:   "Test patchMonitorHandler frequency out of bounds",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, monitor_id: "1", body: `{"frequency_minutes":0}`},
+			get:    GetReturn{monitor: current},
+		},
+		{
+			name:   "Test patchMonitorHandler removing a principal field",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 400, monitor_id: "1", body: `{"url":null}`},
+			get:    GetReturn{monitor: current},
+		},
+		{
+			name:   "Test patchMonitorHandler monitor not found",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 404, monitor_id: "1", body: `{"frequency_minutes":5}`},
+			get:    GetReturn{err: data.ErrMonitorNotFound},
+		},
+		{
+			name:   "Test patchMonitorHandler conflict with another monitor",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 409, monitor_id: "1", body: `{"url":"https://www.bing.com"}`},
+			get:    GetReturn{monitor: current},
+			update: UpdateReturn{err: data.ErrUniqueConstraintViolation},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testObj := data.NewMonitorModelMock()
+			testObj.On("GetById", mock.Anything, int64(1), "", mock.Anything).Return(tt.get.monitor, tt.get.err)
+			expected := tt.expected
+			if expected == nil {
+				expected = func(m data.Monitor) bool { return true }
+			}
+			testObj.On("Update", mock.Anything, mock.MatchedBy(expected), "", mock.Anything).Return(tt.update.monitor, tt.update.err)
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				models:   testObj,
+				checkers: tt.fields.checkers,
+				orgs:     tt.fields.orgs,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("PATCH", "/v1/monitors/:id", app.patchMonitorHandler)
+
+			req := asAdmin(httptest.NewRequest("PATCH", "/v1/monitors/"+tt.args.monitor_id, strings.NewReader(tt.args.body)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
+			}
 		})
 	}
 }
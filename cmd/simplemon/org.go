@@ -0,0 +1,390 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/go-chi/httplog"
+	"github.com/julienschmidt/httprouter"
+)
+
+// requireRole verifies that the user of the request has at least the role in the organization,
+// responding with 404 when the user is not a member and with 403 when its role is lower. The admin
+// has all the roles in all the organizations.
+func (app *Application) requireRole(w http.ResponseWriter, r *http.Request, orgID int64, role data.Role) bool {
+	log := httplog.LogEntry(r.Context())
+	p := contextGetPrincipal(r)
+	if p.admin {
+		return true
+	}
+	got, err := app.orgs.GetRole(r.Context(), orgID, p.email, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return false
+	}
+	if !got.Includes(role) {
+		log.Warn().Msgf("The role %s does not include the role %s", got, role)
+		app.forbiddenResponse(w, r, "The role "+string(got)+" in the organization does not allow this action, it requires the role "+string(role))
+		return false
+	}
+	return true
+}
+
+// requireMember verifies that the contact of a monitor or a channel, that receives its
+// notifications, is a member of its organization, responding with 400 when it is not. The user of
+// the request is a member, verified by requireRole.
+func (app *Application) requireMember(w http.ResponseWriter, r *http.Request, orgID int64, userEmail string) bool {
+	log := httplog.LogEntry(r.Context())
+	if userEmail == contextGetPrincipal(r).email {
+		return true
+	}
+	_, err := app.orgs.GetRole(r.Context(), orgID, userEmail, log)
+	if errors.Is(err, data.ErrNotFound) {
+		log.Warn().Msg("The user_email is not a member of the organization")
+		app.failedValidationResponse(w, r, map[string]string{"user_email": "must be a member of the organization"})
+		return false
+	} else if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return false
+	}
+	return true
+}
+
+// readOrgIDParam reads the id of the organization of the url, responding with 400 when it is invalid.
+func (app *Application) readOrgIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	log := httplog.LogEntry(r.Context())
+	orgID, err := readIDParam(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the organization id")
+		app.badRequestResponse(w, r, "The organization id must be an integer")
+		return 0, false
+	}
+	return orgID, true
+}
+
+// readTeamParams reads the ids of /v1/orgs/:id/teams/:team_id, responding with 400 when they are
+// invalid.
+func (app *Application) readTeamParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	log := httplog.LogEntry(r.Context())
+	teamID, err := readInt64Param(r, "team_id")
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the team id")
+		app.badRequestResponse(w, r, "The team id must be an integer")
+		return 0, 0, false
+	}
+	return orgID, teamID, true
+}
+
+// createOrgHandler creates an organization owned by the user of the request, or by the user_email
+// of the body when it is the admin.
+func (app *Application) createOrgHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Create Organization Handler")
+
+	var input struct {
+		Name      string `json:"name"`
+		UserEmail string `json:"user_email"`
+	}
+	err := readJSON(w, r, &input)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	p := contextGetPrincipal(r)
+	if input.UserEmail == "" {
+		input.UserEmail = p.email
+	}
+	org := data.Org{Name: input.Name}
+	v := validator.New()
+	data.ValidateOrg(v, org)
+	v.Check(validator.IsEmail(input.UserEmail), "user_email", "must be a valid email address")
+	p.validateOwner(v, input.UserEmail)
+	if !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid organization")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	created, err := app.orgs.Insert(r.Context(), org, input.UserEmail, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditOrgCreate, TargetType: "org", TargetID: strconv.FormatInt(created.OrgID, 10), OrgID: created.OrgID}, nil, created)
+	err = writeJSON(w, http.StatusCreated, created, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// getAllOrgsHandler lists the organizations of the user, with its role in each one.
+func (app *Application) getAllOrgsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get All Organizations Handler")
+
+	orgs, err := app.orgs.GetAll(r.Context(), contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, orgs, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) getOrgHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Organization Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok {
+		return
+	}
+	org, err := app.orgs.GetByID(r.Context(), orgID, contextGetPrincipal(r).owner(), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, org, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteOrgHandler deletes an organization without monitors, only its owners can delete it.
+func (app *Application) deleteOrgHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Delete Organization Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok || !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	org, err := app.orgs.GetByID(r.Context(), orgID, "", log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = app.orgs.Delete(r.Context(), orgID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditOrgDelete, TargetType: "org", TargetID: strconv.FormatInt(orgID, 10), OrgID: orgID}, org, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getOrgMembersHandler lists the direct members of the organization, all its members can see them.
+func (app *Application) getOrgMembersHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Organization Members Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok || !app.requireRole(w, r, orgID, data.RoleViewer) {
+		return
+	}
+	members, err := app.orgs.GetMembers(r.Context(), orgID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, members, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// setOrgMemberHandler adds the user of the url to the organization or changes its role (PUT).
+func (app *Application) setOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Set Organization Member Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok {
+		return
+	}
+	var input struct {
+		Role data.Role `json:"role"`
+	}
+	err := readJSON(w, r, &input)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	member := data.OrgMember{
+		OrgID:     orgID,
+		UserEmail: httprouter.ParamsFromContext(r.Context()).ByName("email"),
+		Role:      input.Role,
+	}
+	v := validator.New()
+	data.ValidateOrgMember(v, member)
+	if !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid member")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	if !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	saved, err := app.orgs.SetMember(r.Context(), member, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditOrgSetMember, TargetType: "org_member", TargetID: member.UserEmail, OrgID: orgID}, nil, saved)
+	err = writeJSON(w, http.StatusOK, saved, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) removeOrgMemberHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Remove Organization Member Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok || !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	email := httprouter.ParamsFromContext(r.Context()).ByName("email")
+	err := app.orgs.RemoveMember(r.Context(), orgID, email, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditOrgRemoveMember, TargetType: "org_member", TargetID: email, OrgID: orgID}, nil, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getTeamsHandler lists the teams of the organization with their members.
+func (app *Application) getTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Get Teams Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok || !app.requireRole(w, r, orgID, data.RoleViewer) {
+		return
+	}
+	teams, err := app.orgs.GetTeams(r.Context(), orgID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, teams, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) createTeamHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Create Team Handler")
+
+	orgID, ok := app.readOrgIDParam(w, r)
+	if !ok {
+		return
+	}
+	var input struct {
+		Name string    `json:"name"`
+		Role data.Role `json:"role"`
+	}
+	err := readJSON(w, r, &input)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error decoding the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+	team := data.Team{OrgID: orgID, Name: input.Name, Role: input.Role}
+	v := validator.New()
+	data.ValidateTeam(v, team)
+	if !v.Valid() {
+		log.Warn().Interface("errors", v.Errors).Msg("Invalid team")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	if !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	created, err := app.orgs.InsertTeam(r.Context(), team, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditTeamCreate, TargetType: "team", TargetID: strconv.FormatInt(created.TeamID, 10), OrgID: orgID}, nil, created)
+	err = writeJSON(w, http.StatusCreated, created, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *Application) deleteTeamHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Delete Team Handler")
+
+	orgID, teamID, ok := app.readTeamParams(w, r)
+	if !ok || !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	err := app.orgs.DeleteTeam(r.Context(), orgID, teamID, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditTeamDelete, TargetType: "team", TargetID: strconv.FormatInt(teamID, 10), OrgID: orgID}, nil, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addTeamMemberHandler adds the user of the url to the team (PUT), it can be repeated.
+func (app *Application) addTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Add Team Member Handler")
+
+	orgID, teamID, ok := app.readTeamParams(w, r)
+	if !ok {
+		return
+	}
+	email := httprouter.ParamsFromContext(r.Context()).ByName("email")
+	if !validator.IsEmail(email) {
+		app.failedValidationResponse(w, r, map[string]string{"email": "must be a valid email address"})
+		return
+	}
+	if !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	err := app.orgs.AddTeamMember(r.Context(), orgID, teamID, email, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditTeamAddMember, TargetType: "team", TargetID: strconv.FormatInt(teamID, 10), OrgID: orgID},
+		nil, map[string]string{"user_email": email})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *Application) removeTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Remove Team Member Handler")
+
+	orgID, teamID, ok := app.readTeamParams(w, r)
+	if !ok || !app.requireRole(w, r, orgID, data.RoleOwner) {
+		return
+	}
+	email := httprouter.ParamsFromContext(r.Context()).ByName("email")
+	err := app.orgs.RemoveTeamMember(r.Context(), orgID, teamID, email, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditEvent{Action: data.AuditTeamRemoveMember, TargetType: "team", TargetID: strconv.FormatInt(teamID, 10), OrgID: orgID},
+		map[string]string{"user_email": email}, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
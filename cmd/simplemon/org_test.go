@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestApplication_monitorRoles verifies that the members of an organization can read its monitors,
+// but only the editors and the owners can change them, for the members of the organization.
+func TestApplication_monitorRoles(t *testing.T) {
+	fields := initFields()
+	monitors := data.NewMonitorModelMock()
+	monitors.On("GetById", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(&data.Monitor{
+		MonitorID: 1, OrgID: 1, UserEmail: "jojo@gmail.com", MonitorType: "http", URL: "https://example.com", Method: "GET", FrequencyMinutes: 5,
+	}, nil)
+	monitors.On("GetById", mock.Anything, int64(2), mock.Anything, mock.Anything).Return((*data.Monitor)(nil), data.ErrMonitorNotFound)
+	monitors.On("Delete", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil)
+	monitors.On("List", mock.Anything, mock.Anything, mock.Anything).Return([]data.Monitor{}, "", nil)
+	monitors.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(&data.Monitor{MonitorID: 3}, nil)
+	orgs := data.NewOrgModelMock()
+	orgs.On("GetRole", mock.Anything, int64(1), "viewer@gmail.com", mock.Anything).Return(data.RoleViewer, nil)
+	orgs.On("GetRole", mock.Anything, int64(1), "editor@gmail.com", mock.Anything).Return(data.RoleEditor, nil)
+	orgs.On("GetRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(data.Role(""), data.ErrOrgNotFound)
+	orgs.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return([]data.Org{{OrgID: 1}}, nil)
+	app := &Application{
+		config:   fields.config,
+		logger:   fields.logger,
+		models:   monitors,
+		checkers: fields.checkers,
+		orgs:     orgs,
+	}
+	router := httprouter.New()
+	router.HandlerFunc("GET", "/v1/monitors", app.getAllMonitorsHandler)
+	router.HandlerFunc("POST", "/v1/monitors", app.createMonitorHandler)
+	router.HandlerFunc("GET", "/v1/monitors/:id", app.getMonitorHandler)
+	router.HandlerFunc("PATCH", "/v1/monitors/:id", app.patchMonitorHandler)
+	router.HandlerFunc("DELETE", "/v1/monitors/:id", app.deleteMonitorHandler)
+
+	monitorBody := `{"type": "http", "url": "https://example.com", "method": "GET", "frequency_minutes": 5}`
+	tests := []struct {
+		name               string
+		user               string
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+	}{
+		{name: "Test viewer lists the monitors", user: "viewer@gmail.com", method: "GET", path: "/v1/monitors", expectedStatusCode: 200},
+		{name: "Test viewer gets a monitor", user: "viewer@gmail.com", method: "GET", path: "/v1/monitors/1", expectedStatusCode: 200},
+		{name: "Test viewer can not create a monitor", user: "viewer@gmail.com", method: "POST", path: "/v1/monitors", body: monitorBody, expectedStatusCode: 403},
+		{name: "Test viewer can not change a monitor", user: "viewer@gmail.com", method: "PATCH", path: "/v1/monitors/1", body: `{"frequency_minutes": 10}`, expectedStatusCode: 403},
+		{name: "Test viewer can not delete a monitor", user: "viewer@gmail.com", method: "DELETE", path: "/v1/monitors/1", expectedStatusCode: 403},
+		{name: "Test editor creates a monitor in its only organization", user: "editor@gmail.com", method: "POST", path: "/v1/monitors", body: monitorBody, expectedStatusCode: 201},
+		{name: "Test editor deletes a monitor", user: "editor@gmail.com", method: "DELETE", path: "/v1/monitors/1", expectedStatusCode: 204},
+		{name: "Test monitor of another organization", user: "editor@gmail.com", method: "GET", path: "/v1/monitors/2", expectedStatusCode: 404},
+		{
+			name:               "Test create monitor in another organization",
+			user:               "editor@gmail.com",
+			method:             "POST",
+			path:               "/v1/monitors",
+			body:               `{"org_id": 2, "type": "http", "url": "https://example.com", "method": "GET", "frequency_minutes": 5}`,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Test create monitor of a user out of the organization",
+			user:               "editor@gmail.com",
+			method:             "POST",
+			path:               "/v1/monitors",
+			body:               `{"user_email": "lulu@gmail.com", "type": "http", "url": "https://example.com", "method": "GET", "frequency_minutes": 5}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test give monitor to a user out of the organization",
+			user:               "editor@gmail.com",
+			method:             "PATCH",
+			path:               "/v1/monitors/1",
+			body:               `{"user_email": "lulu@gmail.com"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test move monitor to another organization",
+			user:               "editor@gmail.com",
+			method:             "PATCH",
+			path:               "/v1/monitors/1",
+			body:               `{"org_id": 2}`,
+			expectedStatusCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := asUser(httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)), tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+		})
+	}
+	monitors.AssertCalled(t, "List", mock.Anything, mock.MatchedBy(func(f data.MonitorFilter) bool { return f.Owner == "viewer@gmail.com" }), mock.Anything)
+	monitors.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(m data.Monitor) bool {
+		return m.OrgID == 1 && m.UserEmail == "editor@gmail.com"
+	}), mock.Anything)
+	monitors.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApplication_setOrgMemberHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		user               string
+		email              string
+		body               string
+		setMember          error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Test setOrgMemberHandler owner adds a member",
+			user:               "owner@gmail.com",
+			email:              "dio@gmail.com",
+			body:               `{"role": "editor"}`,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Test setOrgMemberHandler editor can not add a member",
+			user:               "editor@gmail.com",
+			email:              "dio@gmail.com",
+			body:               `{"role": "viewer"}`,
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Test setOrgMemberHandler unknown role",
+			user:               "owner@gmail.com",
+			email:              "dio@gmail.com",
+			body:               `{"role": "admin"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test setOrgMemberHandler invalid email",
+			user:               "owner@gmail.com",
+			email:              "dio",
+			body:               `{"role": "viewer"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test setOrgMemberHandler last owner",
+			user:               "owner@gmail.com",
+			email:              "owner@gmail.com",
+			body:               `{"role": "viewer"}`,
+			setMember:          data.ErrLastOrgOwner,
+			expectedStatusCode: 409,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			orgs := data.NewOrgModelMock()
+			orgs.On("GetRole", mock.Anything, int64(1), "owner@gmail.com", mock.Anything).Return(data.RoleOwner, nil)
+			orgs.On("GetRole", mock.Anything, int64(1), "editor@gmail.com", mock.Anything).Return(data.RoleEditor, nil)
+			orgs.On("SetMember", mock.Anything, mock.Anything, mock.Anything).Return(&data.OrgMember{}, tt.setMember)
+			app := &Application{
+				config: fields.config,
+				logger: fields.logger,
+				orgs:   orgs,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("PUT", "/v1/orgs/:id/members/:email", app.setOrgMemberHandler)
+
+			req := asUser(httptest.NewRequest("PUT", "/v1/orgs/1/members/"+tt.email, strings.NewReader(tt.body)), tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			if w.Code == 200 {
+				orgs.AssertCalled(t, "SetMember", mock.Anything, data.OrgMember{OrgID: 1, UserEmail: tt.email, Role: data.RoleEditor}, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/go-chi/httplog"
+	"github.com/julienschmidt/httprouter"
+)
+
+// pingHandler records a ping sent by the job of a heartbeat monitor to /v1/ping/:token, optionally
+// followed by /start, /success or /fail. A ping without suffix is a success. The body of the request
+// is stored as the message of the ping, so the job can send its output.
+func (app *Application) pingHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Ping Handler")
+
+	params := httprouter.ParamsFromContext(r.Context())
+	kind := params.ByName("kind")
+	if kind == "" {
+		kind = data.PingSuccess
+	}
+	if !validator.PermittedValue(kind, data.PingStart, data.PingSuccess, data.PingFail) {
+		app.notFoundResponse(w, r, "The requested resource could not be found")
+		return
+	}
+	body, err := readBody(w, r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error reading the request body")
+		app.invalidBodyResponse(w, r, err)
+		return
+	}
+
+	monitor, err := app.models.GetByURL(r.Context(), checker.TypeHeartbeat, checker.PingPath+params.ByName("token"), log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	message := string(body)
+	if len(message) > data.MaxPingMessageLength {
+		message = strings.ToValidUTF8(message[:data.MaxPingMessageLength], "")
+	}
+	ping, err := app.pings.Insert(r.Context(), data.Ping{
+		MonitorID:  monitor.MonitorID,
+		Kind:       kind,
+		ReceivedAt: time.Now().UTC(),
+		Message:    message,
+	}, log)
+	if err != nil {
+		app.modelErrorResponse(w, r, err)
+		return
+	}
+	err = writeJSON(w, http.StatusOK, ping, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_pingHandler(t *testing.T) {
+	type args struct {
+		expectedStatusCode int
+		path               string
+		body               string
+	}
+	type GetReturn struct {
+		monitor *data.Monitor
+		err     error
+	}
+	type InsertReturn struct {
+		ping *data.Ping
+		err  error
+	}
+	token := "0123456789abcdefghij_-"
+	monitor := &data.Monitor{MonitorID: 1, MonitorType: checker.TypeHeartbeat, URL: checker.PingPath + token, FrequencyMinutes: 60}
+	tests := []struct {
+		name     string
+		fields   Fields
+		args     args
+		get      GetReturn
+		insert   InsertReturn
+		expected func(p data.Ping) bool // verify the ping sent to the Insert method
+	}{
+		{
+			name:   "Test pingHandler success without suffix",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, path: "/v1/ping/" + token},
+			get:    GetReturn{monitor: monitor},
+			insert: InsertReturn{ping: &data.Ping{PingID: 1, MonitorID: 1, Kind: data.PingSuccess}},
+			expected: func(p data.Ping) bool {
+				return p.MonitorID == 1 && p.Kind == data.PingSuccess && !p.ReceivedAt.IsZero()
+			},
+		},
+		{
+			name:   "Test pingHandler start",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, path: "/v1/ping/" + token + "/start"},
+			get:    GetReturn{monitor: monitor},
+			insert: InsertReturn{ping: &data.Ping{PingID: 1, MonitorID: 1, Kind: data.PingStart}},
+			expected: func(p data.Ping) bool {
+				return p.Kind == data.PingStart
+			},
+		},
+		{
+			name:   "Test pingHandler fail with the output of the job",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, path: "/v1/ping/" + token + "/fail", body: "disk full"},
+			get:    GetReturn{monitor: monitor},
+			insert: InsertReturn{ping: &data.Ping{PingID: 1, MonitorID: 1, Kind: data.PingFail}},
+			expected: func(p data.Ping) bool {
+				return p.Kind == data.PingFail && p.Message == "disk full"
+			},
+		},
+		{
+			name:   "Test pingHandler long message is truncated",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 200, path: "/v1/ping/" + token + "/fail", body: strings.Repeat("a", 5000)},
+			get:    GetReturn{monitor: monitor},
+			insert: InsertReturn{ping: &data.Ping{PingID: 1, MonitorID: 1, Kind: data.PingFail}},
+			expected: func(p data.Ping) bool {
+				return len(p.Message) == data.MaxPingMessageLength
+			},
+		},
+		{
+			name:   "Test pingHandler unknown suffix",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 404, path: "/v1/ping/" + token + "/finish"},
+			get:    GetReturn{monitor: monitor},
+		},
+		{
+			name:   "Test pingHandler unknown token",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 404, path: "/v1/ping/jojo"},
+			get:    GetReturn{err: data.ErrMonitorNotFound},
+		},
+		{
+			name:   "Test pingHandler database generic error",
+			fields: Fields(initFields()),
+			args:   args{expectedStatusCode: 500, path: "/v1/ping/" + token},
+			get:    GetReturn{monitor: monitor},
+			insert: InsertReturn{err: errors.New("database generic error")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitors := data.NewMonitorModelMock()
+			monitors.On("GetByURL", mock.Anything, checker.TypeHeartbeat, mock.Anything, mock.Anything).Return(tt.get.monitor, tt.get.err)
+			expected := tt.expected
+			if expected == nil {
+				expected = func(p data.Ping) bool { return true }
+			}
+			pings := data.NewPingModelMock()
+			pings.On("Insert", mock.Anything, mock.MatchedBy(expected), mock.Anything).Return(tt.insert.ping, tt.insert.err)
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				models:   monitors,
+				checkers: tt.fields.checkers,
+				pings:    pings,
+			}
+			router := httprouter.New()
+			router.HandlerFunc("POST", "/v1/ping/:token", app.pingHandler)
+			router.HandlerFunc("POST", "/v1/ping/:token/:kind", app.pingHandler)
+
+			req := httptest.NewRequest("POST", tt.args.path, strings.NewReader(tt.args.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.args.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v", tt.args.expectedStatusCode, w.Code)
+			}
+		})
+	}
+}
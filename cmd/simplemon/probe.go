@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/checker"
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/go-chi/httplog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// probeModule describes how /probe checks a target, like the modules of the blackbox exporter.
+type probeModule struct {
+	monitorType string
+	method      string
+}
+
+// probeModules are the modules accepted by /probe. The names follow the example config of the
+// blackbox exporter, so the existing scrape configs keep working.
+var probeModules = map[string]probeModule{
+	"http_2xx":      {monitorType: checker.TypeHTTP, method: http.MethodGet},
+	"http_post_2xx": {monitorType: checker.TypeHTTP, method: http.MethodPost},
+	"tcp_connect":   {monitorType: checker.TypeTCP},
+	"tls_connect":   {monitorType: checker.TypeTLS},
+	"dns":           {monitorType: checker.TypeDNS},
+}
+
+const (
+	defaultProbeModule = "http_2xx"
+	// defaultProbeTimeout is used when Prometheus does not send its scrape timeout, it is the
+	// default scrape_timeout of Prometheus.
+	defaultProbeTimeout = 10 * time.Second
+	// probeTimeoutOffset is subtracted from the scrape timeout, so the metrics are written before
+	// Prometheus gives up on the scrape.
+	probeTimeoutOffset = 500 * time.Millisecond
+)
+
+func probeModuleNames() []string {
+	names := make([]string, 0, len(probeModules))
+	for name := range probeModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// probeHandler checks the target once with the checker of the module and responds with the result
+// as Prometheus metrics, compatible with the /probe endpoint of the blackbox exporter. A failed
+// check is reported by probe_success, only an invalid request is an error.
+func (app *Application) probeHandler(w http.ResponseWriter, r *http.Request) {
+	log := httplog.LogEntry(r.Context())
+	log.Info().Msg("Starting Probe Handler")
+
+	query := r.URL.Query()
+	target := query.Get("target")
+	moduleName := query.Get("module")
+	if moduleName == "" {
+		moduleName = defaultProbeModule
+	}
+	v := validator.New()
+	v.Check(target != "", "target", "must be provided")
+	module, ok := probeModules[moduleName]
+	v.Check(ok, "module", "must be one of "+strings.Join(probeModuleNames(), ", "))
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	monitor := data.Monitor{MonitorType: module.monitorType, Method: module.method, URL: target}
+	//the blackbox exporter accepts http targets without scheme
+	if module.monitorType == checker.TypeHTTP && !strings.Contains(target, "://") {
+		monitor.URL = "http://" + target
+	}
+	var validationErr *data.ValidationError
+	if err := app.checkers.Validate(monitor); errors.As(err, &validationErr) {
+		log.Warn().Interface("errors", validationErr.Fields).Msg("Invalid probe target")
+		message := validationErr.Fields["url"]
+		if message == "" {
+			message = "is not a valid target for the module " + moduleName
+		}
+		app.failedValidationResponse(w, r, map[string]string{"target": message})
+		return
+	} else if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout(r))
+	defer cancel()
+	start := time.Now()
+	result := app.checkers.Check(ctx, monitor)
+	duration := time.Since(start)
+	if !result.Success {
+		log.Info().Str("target", target).Str("module", moduleName).Str("error", result.Error).Msg("Probe failed")
+	}
+
+	registry := prometheus.NewRegistry()
+	gauge := func(name, help string, value float64) {
+		registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 { return value }))
+	}
+	success := 0.0
+	if result.Success {
+		success = 1
+	}
+	gauge("probe_success", "Whether the probe succeeded.", success)
+	gauge("probe_duration_seconds", "How many seconds the probe took to complete.", duration.Seconds())
+	if module.monitorType == checker.TypeHTTP {
+		gauge("probe_http_status_code", "Response HTTP status code, 0 without response.", float64(result.StatusCode))
+		gauge("probe_http_content_length", "Length of the HTTP response body.", float64(result.ResponseSize))
+	}
+	if !result.CertExpiresAt.IsZero() {
+		gauge("probe_ssl_earliest_cert_expiry", "Unix time of the expiry of the certificate served by the target.", float64(result.CertExpiresAt.Unix()))
+	}
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// probeTimeout is the time available to the probe, based on the scrape timeout sent by Prometheus.
+func probeTimeout(r *http.Request) time.Duration {
+	seconds, err := strconv.ParseFloat(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return defaultProbeTimeout
+	}
+	timeout := time.Duration(seconds*float64(time.Second)) - probeTimeoutOffset
+	if timeout <= 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return timeout
+}
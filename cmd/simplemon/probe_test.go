@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplication_probeHandler(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+	targetURL, _ := url.Parse(target.URL)
+
+	tests := []struct {
+		name               string
+		fields             Fields
+		query              string
+		expectedStatusCode int
+		expectedMetrics    []string
+	}{
+		{
+			name:               "Test probeHandler http success",
+			fields:             Fields(initFields()),
+			query:              "module=http_2xx&target=" + url.QueryEscape(target.URL),
+			expectedStatusCode: 200,
+			expectedMetrics:    []string{"probe_success 1\n", "probe_http_status_code 200\n", "probe_http_content_length 2\n", "probe_duration_seconds "},
+		},
+		{
+			name:               "Test probeHandler http failure",
+			fields:             Fields(initFields()),
+			query:              "module=http_2xx&target=" + url.QueryEscape(target.URL+"/down"),
+			expectedStatusCode: 200,
+			expectedMetrics:    []string{"probe_success 0\n", "probe_http_status_code 503\n"},
+		},
+		{
+			name:               "Test probeHandler default module and target without scheme",
+			fields:             Fields(initFields()),
+			query:              "target=" + targetURL.Host,
+			expectedStatusCode: 200,
+			expectedMetrics:    []string{"probe_success 1\n"},
+		},
+		{
+			name:               "Test probeHandler tcp success",
+			fields:             Fields(initFields()),
+			query:              "module=tcp_connect&target=" + targetURL.Host,
+			expectedStatusCode: 200,
+			expectedMetrics:    []string{"probe_success 1\n"},
+		},
+		{
+			name:               "Test probeHandler missing target",
+			fields:             Fields(initFields()),
+			query:              "module=http_2xx",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test probeHandler unknown module",
+			fields:             Fields(initFields()),
+			query:              "module=icmp&target=example.com",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Test probeHandler invalid target",
+			fields:             Fields(initFields()),
+			query:              "module=tcp_connect&target=example.com",
+			expectedStatusCode: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Application{
+				config:   tt.fields.config,
+				logger:   tt.fields.logger,
+				checkers: tt.fields.checkers,
+			}
+			req := httptest.NewRequest("GET", "/probe?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			app.probeHandler(w, req)
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %v, got %v: %s", tt.expectedStatusCode, w.Code, w.Body.String())
+			}
+			for _, metric := range tt.expectedMetrics {
+				if !strings.Contains(w.Body.String(), metric) {
+					t.Errorf("Expected the metric %q, got %s", metric, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{header: "", expected: defaultProbeTimeout},
+		{header: "jojo", expected: defaultProbeTimeout},
+		{header: "15", expected: 14500 * time.Millisecond},
+		{header: "0.25", expected: 250 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/probe", nil)
+		req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tt.header)
+		if got := probeTimeout(req); got != tt.expected {
+			t.Errorf("Expected timeout %v for %q, got %v", tt.expected, tt.header, got)
+		}
+	}
+}
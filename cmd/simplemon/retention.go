@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// purgeInterval is how often the deleted monitors past the retention period are purged.
+const purgeInterval = time.Hour
+
+// purgeDeletedMonitorsEvery purges the monitors deleted for longer than the retention period, right
+// away and then every interval, until the context is done.
+func (app *Application) purgeDeletedMonitorsEvery(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		app.purgeDeletedMonitors(ctx, retention)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedMonitors removes for good the monitors deleted before the retention period, with
+// their history. They can not be restored anymore.
+func (app *Application) purgeDeletedMonitors(ctx context.Context, retention time.Duration) {
+	log := app.logger.With().Str("job", "purge_monitors").Logger()
+	purged, err := app.models.Purge(ctx, time.Now().Add(-retention), log)
+	if err != nil {
+		log.Err(err).Msg("Error purging the deleted monitors")
+		return
+	}
+	if purged > 0 {
+		log.Info().Int64("purged", purged).Msg("Purged the deleted monitors past the retention period")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplication_purgeDeletedMonitors(t *testing.T) {
+	tests := []struct {
+		name     string
+		purgeErr error
+	}{
+		{name: "Test purgeDeletedMonitors purges the monitors past the retention period"},
+		{name: "Test purgeDeletedMonitors database generic error", purgeErr: errors.New("database generic error")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := initFields()
+			monitors := data.NewMonitorModelMock()
+			monitors.On("Purge", mock.Anything, mock.Anything, mock.Anything).Return(int64(2), tt.purgeErr)
+			app := &Application{
+				config: fields.config,
+				logger: fields.logger,
+				models: monitors,
+			}
+			retention := 24 * time.Hour
+			start := time.Now()
+			app.purgeDeletedMonitors(context.Background(), retention)
+			monitors.AssertCalled(t, "Purge", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+				return !before.Before(start.Add(-retention)) && !before.After(time.Now().Add(-retention))
+			}), mock.Anything)
+		})
+	}
+}
@@ -12,13 +12,73 @@ func (app *Application) routes() http.Handler {
 	httpLogMiddleware := httplog.RequestLogger(app.logger)
 
 	router := httprouter.New()
+	// handlePublic registers the handler with the logs and the metrics, labeled by the route
+	handlePublic := func(method, route string, handler http.HandlerFunc) {
+		router.HandlerFunc(method, route, addMiddleware(app.metrics.instrument(route, handler), httpLogMiddleware))
+	}
+	// handle registers the handler like handlePublic, only for the requests with a valid API key
+	handle := func(method, route string, handler http.HandlerFunc) {
+		handlePublic(method, route, addMiddleware(handler, app.authenticate))
+	}
+	router.NotFound = addMiddleware(app.metrics.instrument("unmatched", func(w http.ResponseWriter, r *http.Request) {
+		app.notFoundResponse(w, r, "The requested resource could not be found")
+	}), httpLogMiddleware)
+	router.MethodNotAllowed = addMiddleware(app.metrics.instrument("unmatched", app.methodNotAllowedResponse), httpLogMiddleware)
 	//healthcheck route
-	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", addMiddleware(app.healthcheckHandler, httpLogMiddleware))
+	handlePublic(http.MethodGet, "/v1/healthcheck", app.healthcheckHandler)
 	//monitor routes
-	router.HandlerFunc(http.MethodPost, "/v1/monitors", addMiddleware(app.createMonitorHandler, httpLogMiddleware))
-	router.HandlerFunc(http.MethodGet, "/v1/monitors/:id", addMiddleware(app.getMonitorHandler, httpLogMiddleware))
-	router.HandlerFunc(http.MethodDelete, "/v1/monitors/:id", addMiddleware(app.deleteMonitorHandler, httpLogMiddleware))
-	router.HandlerFunc(http.MethodGet, "/v1/monitors", addMiddleware(app.getAllMonitorsHandler, httpLogMiddleware))
+	handle(http.MethodPost, "/v1/monitors", app.createMonitorHandler)
+	handle(http.MethodGet, "/v1/monitors/:id", app.getMonitorHandler)
+	handle(http.MethodPut, "/v1/monitors/:id", app.updateMonitorHandler)
+	handle(http.MethodPatch, "/v1/monitors/:id", app.patchMonitorHandler)
+	handle(http.MethodDelete, "/v1/monitors/:id", app.deleteMonitorHandler)
+	handle(http.MethodPost, "/v1/monitors/:id/restore", app.restoreMonitorHandler)
+	handle(http.MethodGet, "/v1/monitors", app.getAllMonitorsHandler)
+	handle(http.MethodGet, "/v1/monitors/:id/results", app.getMonitorResultsHandler)
+	handle(http.MethodGet, "/v1/monitors/:id/incidents", app.getMonitorIncidentsHandler)
+	handle(http.MethodGet, "/v1/monitors/:id/channels", app.getMonitorChannelsHandler)
+	handle(http.MethodPut, "/v1/monitors/:id/channels/:channel_id", app.linkMonitorChannelHandler)
+	handle(http.MethodDelete, "/v1/monitors/:id/channels/:channel_id", app.unlinkMonitorChannelHandler)
+	//channel routes
+	handle(http.MethodPost, "/v1/channels", app.createChannelHandler)
+	handle(http.MethodGet, "/v1/channels", app.getAllChannelsHandler)
+	handle(http.MethodGet, "/v1/channels/:id", app.getChannelHandler)
+	handle(http.MethodPut, "/v1/channels/:id", app.updateChannelHandler)
+	handle(http.MethodDelete, "/v1/channels/:id", app.deleteChannelHandler)
+	handle(http.MethodPost, "/v1/channels/:id/test", app.testChannelHandler)
+	//api key routes
+	handle(http.MethodPost, "/v1/api-keys", app.createAPIKeyHandler)
+	handle(http.MethodGet, "/v1/api-keys", app.getAllAPIKeysHandler)
+	handle(http.MethodDelete, "/v1/api-keys/:id", app.deleteAPIKeyHandler)
+	//organization routes
+	handle(http.MethodPost, "/v1/orgs", app.createOrgHandler)
+	handle(http.MethodGet, "/v1/orgs", app.getAllOrgsHandler)
+	handle(http.MethodGet, "/v1/orgs/:id", app.getOrgHandler)
+	handle(http.MethodDelete, "/v1/orgs/:id", app.deleteOrgHandler)
+	handle(http.MethodGet, "/v1/orgs/:id/members", app.getOrgMembersHandler)
+	handle(http.MethodPut, "/v1/orgs/:id/members/:email", app.setOrgMemberHandler)
+	handle(http.MethodDelete, "/v1/orgs/:id/members/:email", app.removeOrgMemberHandler)
+	handle(http.MethodGet, "/v1/orgs/:id/teams", app.getTeamsHandler)
+	handle(http.MethodPost, "/v1/orgs/:id/teams", app.createTeamHandler)
+	handle(http.MethodDelete, "/v1/orgs/:id/teams/:team_id", app.deleteTeamHandler)
+	handle(http.MethodPut, "/v1/orgs/:id/teams/:team_id/members/:email", app.addTeamMemberHandler)
+	handle(http.MethodDelete, "/v1/orgs/:id/teams/:team_id/members/:email", app.removeTeamMemberHandler)
+	//audit routes
+	handle(http.MethodGet, "/v1/audit", app.getAuditEventsHandler)
+	//heartbeat routes, authenticated by the token of the monitor
+	handlePublic(http.MethodPost, "/v1/ping/:token", app.pingHandler)
+	handlePublic(http.MethodPost, "/v1/ping/:token/:kind", app.pingHandler)
+	//certificate routes
+	handle(http.MethodGet, "/v1/certificates", app.getExpiringCertificatesHandler)
+	//incident routes
+	handle(http.MethodGet, "/v1/incidents", app.getAllIncidentsHandler)
+	//probe route, compatible with the blackbox exporter
+	handle(http.MethodGet, "/probe", app.probeHandler)
+
+	//metrics routes, outside of the API so they are not counted in its metrics
+	if app.metrics != nil && app.config.metricsToken != "" {
+		router.HandlerFunc(http.MethodGet, "/metrics", addMiddleware(app.metricsHandler, httpLogMiddleware))
+	}
 
 	//swagger routes
 	opts := middleware.SwaggerUIOpts{SpecURL: "openapi.yaml"}
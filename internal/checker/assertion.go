@@ -0,0 +1,242 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of the assertions of the http monitors.
+const (
+	AssertStatusCode   = "status_code"   // the status code is one of StatusCodes
+	AssertBodyContains = "body_contains" // the body contains Value
+	AssertBodyRegex    = "body_regex"    // the body matches the regular expression Value
+	AssertJSONPath     = "json_path"     // the JSON value at Path of the body equals Equals
+	AssertHeader       = "header"        // the response has the Header, equal to Value if it is set
+	AssertResponseTime = "response_time" // the response is received in at most MaxMs
+)
+
+var AssertionTypes = []string{AssertStatusCode, AssertBodyContains, AssertBodyRegex, AssertJSONPath, AssertHeader, AssertResponseTime}
+
+const (
+	MaxAssertions = 20
+	// maxActualLength is the max length of the actual value recorded in the result.
+	maxActualLength = 256
+)
+
+// Assertion is a condition on the response of an http monitor. Only the fields used by its type
+// are set.
+type Assertion struct {
+	Type        string          `json:"type"`
+	StatusCodes []int           `json:"status_codes,omitempty"`
+	Value       string          `json:"value,omitempty"`
+	Path        string          `json:"path,omitempty"`
+	Equals      json.RawMessage `json:"equals,omitempty"`
+	Header      string          `json:"header,omitempty"`
+	MaxMs       int64           `json:"max_ms,omitempty"`
+}
+
+// String describes the assertion, it is recorded in the result when the assertion fails.
+func (a Assertion) String() string {
+	switch a.Type {
+	case AssertStatusCode:
+		return fmt.Sprintf("status_code in %v", a.StatusCodes)
+	case AssertBodyContains, AssertBodyRegex:
+		return fmt.Sprintf("%s %q", a.Type, a.Value)
+	case AssertJSONPath:
+		return fmt.Sprintf("json_path %s equals %s", a.Path, a.Equals)
+	case AssertHeader:
+		if a.Value == "" {
+			return fmt.Sprintf("header %s present", a.Header)
+		}
+		return fmt.Sprintf("header %s equals %q", a.Header, a.Value)
+	case AssertResponseTime:
+		return fmt.Sprintf("response_time <= %dms", a.MaxMs)
+	default:
+		return a.Type
+	}
+}
+
+// validate returns the problem of the assertion, or nil if it is valid.
+func (a Assertion) validate() error {
+	switch a.Type {
+	case AssertStatusCode:
+		if len(a.StatusCodes) == 0 {
+			return errors.New("status_codes is required")
+		}
+		for _, code := range a.StatusCodes {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("status code %d must be between 100 and 599", code)
+			}
+		}
+	case AssertBodyContains:
+		if a.Value == "" {
+			return errors.New("value is required")
+		}
+	case AssertBodyRegex:
+		if _, err := regexp.Compile(a.Value); err != nil || a.Value == "" {
+			return errors.New("value must be a valid regular expression")
+		}
+	case AssertJSONPath:
+		if _, err := parseJSONPath(a.Path); err != nil {
+			return err
+		}
+		if !json.Valid(a.Equals) {
+			return errors.New("equals must be a JSON value")
+		}
+	case AssertHeader:
+		if a.Header == "" {
+			return errors.New("header is required")
+		}
+	case AssertResponseTime:
+		if a.MaxMs <= 0 {
+			return errors.New("max_ms must be positive")
+		}
+	default:
+		return fmt.Errorf("type must be one of %s", strings.Join(AssertionTypes, ", "))
+	}
+	return nil
+}
+
+// check verifies the assertion against the response, returning the actual value when it fails.
+func (a Assertion) check(resp *http.Response, body []byte, latency time.Duration) (string, bool) {
+	switch a.Type {
+	case AssertStatusCode:
+		for _, code := range a.StatusCodes {
+			if resp.StatusCode == code {
+				return "", true
+			}
+		}
+		return strconv.Itoa(resp.StatusCode), false
+	case AssertBodyContains:
+		return truncate(string(body)), bytes.Contains(body, []byte(a.Value))
+	case AssertBodyRegex:
+		rx, err := regexp.Compile(a.Value)
+		if err != nil {
+			return err.Error(), false
+		}
+		return truncate(string(body)), rx.Match(body)
+	case AssertJSONPath:
+		var doc, expected interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "body is not JSON", false
+		}
+		if err := json.Unmarshal(a.Equals, &expected); err != nil {
+			return err.Error(), false
+		}
+		path, err := parseJSONPath(a.Path)
+		if err != nil {
+			return err.Error(), false
+		}
+		value, ok := path.lookup(doc)
+		if !ok {
+			return "missing", false
+		}
+		actual, _ := json.Marshal(value)
+		canonical, _ := json.Marshal(expected)
+		return truncate(string(actual)), bytes.Equal(actual, canonical)
+	case AssertHeader:
+		values, ok := resp.Header[http.CanonicalHeaderKey(a.Header)]
+		if !ok {
+			return "missing", false
+		}
+		actual := strings.Join(values, ", ")
+		return truncate(actual), a.Value == "" || actual == a.Value
+	case AssertResponseTime:
+		return fmt.Sprintf("%dms", latency.Milliseconds()), latency.Milliseconds() <= a.MaxMs
+	default:
+		return "", false
+	}
+}
+
+// truncate limits the length of the actual values recorded in the results.
+func truncate(value string) string {
+	if len(value) <= maxActualLength {
+		return value
+	}
+	value = value[:maxActualLength]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value + "..."
+}
+
+// jsonPath is a parsed subset of JSONPath: the root $ followed by .name, ["name"] and [index]
+// steps, like $.items[0].status or $["content-type"].
+type jsonPath []interface{} // each step is a string key or an int index
+
+func parseJSONPath(path string) (jsonPath, error) {
+	invalid := fmt.Errorf("path %q must be a JSON path like $.items[0].status", path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, invalid
+	}
+	var steps jsonPath
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, invalid
+			}
+			steps = append(steps, key)
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, `["`):
+			end := strings.Index(rest, `"]`)
+			if end < 2 {
+				return nil, invalid
+			}
+			steps = append(steps, rest[2:end])
+			rest = rest[end+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, invalid
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, invalid
+			}
+			steps = append(steps, index)
+			rest = rest[end+1:]
+		default:
+			return nil, invalid
+		}
+	}
+	return steps, nil
+}
+
+// lookup returns the value at the path of the decoded JSON document.
+func (p jsonPath) lookup(doc interface{}) (interface{}, bool) {
+	current := doc
+	for _, step := range p {
+		switch step := step.(type) {
+		case string:
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[step]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := current.([]interface{})
+			if !ok || step >= len(array) {
+				return nil, false
+			}
+			current = array[step]
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,173 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func httpMonitor(url string, assertions ...Assertion) data.Monitor {
+	raw, _ := json.Marshal(HTTPConfig{Assertions: assertions})
+	return data.Monitor{MonitorType: TypeHTTP, URL: url, Method: "GET", Config: raw}
+}
+
+func TestHTTPChecker_CheckAssertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Cache", "HIT")
+			w.Write([]byte(`{"status": "up", "checks": [{"name": "db", "ok": true, "latency": 3}]}`))
+		case "/maintenance":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("down for maintenance"))
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name            string
+		monitor         data.Monitor
+		success         bool
+		errorClass      ErrorClass
+		failedAssertion string
+		actualValue     string
+	}{
+		{
+			name: "Test Check all the assertions pass",
+			monitor: httpMonitor(srv.URL+"/status",
+				Assertion{Type: AssertStatusCode, StatusCodes: []int{200, 204}},
+				Assertion{Type: AssertBodyContains, Value: `"up"`},
+				Assertion{Type: AssertBodyRegex, Value: `"status":\s*"up"`},
+				Assertion{Type: AssertJSONPath, Path: "$.status", Equals: json.RawMessage(`"up"`)},
+				Assertion{Type: AssertJSONPath, Path: "$.checks[0]", Equals: json.RawMessage(`{"ok": true, "name": "db", "latency": 3.0}`)},
+				Assertion{Type: AssertHeader, Header: "x-cache"},
+				Assertion{Type: AssertHeader, Header: "Content-Type", Value: "application/json"},
+				Assertion{Type: AssertResponseTime, MaxMs: 5000},
+			),
+			success: true,
+		},
+		{
+			name:            "Test Check expected error status code",
+			monitor:         httpMonitor(srv.URL+"/maintenance", Assertion{Type: AssertStatusCode, StatusCodes: []int{503}}),
+			success:         true,
+			failedAssertion: "",
+		},
+		{
+			name:            "Test Check unexpected status code",
+			monitor:         httpMonitor(srv.URL+"/status", Assertion{Type: AssertStatusCode, StatusCodes: []int{201}}),
+			errorClass:      ErrorClassHTTPStatus,
+			failedAssertion: "status_code in [201]",
+			actualValue:     "200",
+		},
+		{
+			name:            "Test Check error status code without status assertion",
+			monitor:         httpMonitor(srv.URL+"/maintenance", Assertion{Type: AssertBodyContains, Value: "maintenance"}),
+			errorClass:      ErrorClassHTTPStatus,
+			failedAssertion: "",
+		},
+		{
+			name:            "Test Check body does not contain",
+			monitor:         httpMonitor(srv.URL+"/status", Assertion{Type: AssertBodyContains, Value: "down"}),
+			errorClass:      ErrorClassAssertion,
+			failedAssertion: `body_contains "down"`,
+			actualValue:     `{"status": "up", "checks": [{"name": "db", "ok": true, "latency": 3}]}`,
+		},
+		{
+			name:            "Test Check json path not equal",
+			monitor:         httpMonitor(srv.URL+"/status", Assertion{Type: AssertJSONPath, Path: "$.checks[0].ok", Equals: json.RawMessage(`false`)}),
+			errorClass:      ErrorClassAssertion,
+			failedAssertion: "json_path $.checks[0].ok equals false",
+			actualValue:     "true",
+		},
+		{
+			name:            "Test Check json path missing",
+			monitor:         httpMonitor(srv.URL+"/status", Assertion{Type: AssertJSONPath, Path: "$.checks[1].ok", Equals: json.RawMessage(`true`)}),
+			errorClass:      ErrorClassAssertion,
+			failedAssertion: "json_path $.checks[1].ok equals true",
+			actualValue:     "missing",
+		},
+		{
+			name:            "Test Check header missing",
+			monitor:         httpMonitor(srv.URL+"/status", Assertion{Type: AssertHeader, Header: "ETag"}),
+			errorClass:      ErrorClassAssertion,
+			failedAssertion: "header ETag present",
+			actualValue:     "missing",
+		},
+		{
+			name:            "Test Check header value",
+			monitor:         httpMonitor(srv.URL+"/status", Assertion{Type: AssertHeader, Header: "X-Cache", Value: "MISS"}),
+			errorClass:      ErrorClassAssertion,
+			failedAssertion: `header X-Cache equals "MISS"`,
+			actualValue:     "HIT",
+		},
+		{
+			name:            "Test Check response time",
+			monitor:         httpMonitor(srv.URL+"/slow", Assertion{Type: AssertResponseTime, MaxMs: 10}),
+			errorClass:      ErrorClassAssertion,
+			failedAssertion: "response_time <= 10ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewHTTPChecker(time.Second)
+			result := c.Check(context.Background(), tt.monitor)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			assert.Equal(t, tt.errorClass, result.ErrorClass)
+			assert.Equal(t, tt.failedAssertion, result.FailedAssertion)
+			if tt.actualValue != "" {
+				assert.Equal(t, tt.actualValue, result.ActualValue)
+			}
+		})
+	}
+}
+
+func TestHTTPChecker_ValidateAssertions(t *testing.T) {
+	c := NewHTTPChecker(0)
+	valid := []Assertion{
+		{Type: AssertStatusCode, StatusCodes: []int{200}},
+		{Type: AssertBodyRegex, Value: "^ok$"},
+		{Type: AssertJSONPath, Path: `$.items[0]["content-type"]`, Equals: json.RawMessage(`null`)},
+		{Type: AssertHeader, Header: "ETag"},
+		{Type: AssertResponseTime, MaxMs: 500},
+	}
+	assert.NoError(t, c.Validate(httpMonitor("https://www.google.com", valid...)))
+
+	invalid := []Assertion{
+		{Type: "status"},
+		{Type: AssertStatusCode},
+		{Type: AssertStatusCode, StatusCodes: []int{99}},
+		{Type: AssertBodyContains},
+		{Type: AssertBodyRegex, Value: "(ok"},
+		{Type: AssertJSONPath, Path: "status", Equals: json.RawMessage(`"up"`)},
+		{Type: AssertJSONPath, Path: "$.status"},
+		{Type: AssertHeader},
+		{Type: AssertResponseTime},
+	}
+	for _, assertion := range invalid {
+		assert.Error(t, c.Validate(httpMonitor("https://www.google.com", assertion)), assertion.String())
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	path, err := parseJSONPath(`$.items[1]["content-type"].value`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, jsonPath{"items", 1, "content-type", "value"}, path)
+	}
+	path, err = parseJSONPath("$")
+	if assert.NoError(t, err) {
+		assert.Empty(t, path)
+	}
+	for _, invalid := range []string{"", "items", "$..items", "$.items[", "$.items[-1]", "$[a]"} {
+		_, err := parseJSONPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,123 @@
+// The checker package contains the executors that run the checks described by the monitors and
+// report what happened in a Result.
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+// ErrorClass groups the errors of a check in a few well known categories, so they can be
+// aggregated and alerted on without parsing the error messages.
+type ErrorClass string
+
+const (
+	ErrorClassNone              ErrorClass = ""
+	ErrorClassInvalidMonitor    ErrorClass = "invalid_monitor"
+	ErrorClassDNS               ErrorClass = "dns"
+	ErrorClassConnectionRefused ErrorClass = "connection_refused"
+	ErrorClassTimeout           ErrorClass = "timeout"
+	ErrorClassTLS               ErrorClass = "tls"
+	ErrorClassConnection        ErrorClass = "connection"
+	ErrorClassHTTPStatus        ErrorClass = "http_status"
+	ErrorClassCertExpiring      ErrorClass = "cert_expiring"
+	ErrorClassAssertion         ErrorClass = "assertion"
+	ErrorClassHeartbeatMissed   ErrorClass = "heartbeat_missed"
+	ErrorClassHeartbeatFailed   ErrorClass = "heartbeat_failed"
+)
+
+// TimeoutConfig is embedded in the config of the monitor types that connect to the monitored
+// endpoint.
+type TimeoutConfig struct {
+	// TimeoutSeconds overrides the default timeout of the checker for this monitor.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// validateTimeout checks the timeout of the config.
+func (c TimeoutConfig) validateTimeout(v *validator.Validator) {
+	v.Check(c.TimeoutSeconds >= 0, "config", "timeout_seconds must not be negative")
+}
+
+// timeout returns the timeout of the monitor, the default of the checker when it is not overridden.
+func (c TimeoutConfig) timeout(defaultTimeout time.Duration) time.Duration {
+	if c.TimeoutSeconds > 0 {
+		return time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+// Result is what happened when a monitor was checked.
+type Result struct {
+	MonitorID    int64
+	CheckedAt    time.Time
+	Success      bool
+	StatusCode   int
+	Latency      time.Duration
+	ResponseSize int64
+	ResolvedIP   string // IP address the check connected to, if the type records it
+	ErrorClass   ErrorClass
+	Error        string
+
+	// Assertion that failed and the value it got, recorded by the types that have assertions.
+	FailedAssertion string
+	ActualValue     string
+
+	// Leaf certificate served by the monitored endpoint, recorded by the types that use TLS.
+	CertExpiresAt time.Time
+	CertIssuer    string
+}
+
+// failAssertion marks the result as failed because of the assertion.
+func (r *Result) failAssertion(class ErrorClass, assertion, actual string) {
+	r.fail(class, fmt.Errorf("assertion failed: %s, got %s", assertion, actual))
+	r.FailedAssertion = assertion
+	r.ActualValue = actual
+}
+
+// recordCertificate records the expiry and the issuer of the leaf certificate.
+func (r *Result) recordCertificate(leaf *x509.Certificate) {
+	r.CertExpiresAt = leaf.NotAfter.UTC()
+	r.CertIssuer = leaf.Issuer.String()
+}
+
+// fail marks the result as failed with the given class and error.
+func (r *Result) fail(class ErrorClass, err error) {
+	r.Success = false
+	r.ErrorClass = class
+	r.Error = err.Error()
+}
+
+// classifyError maps the errors returned by the network stack to an ErrorClass.
+func classifyError(err error) ErrorClass {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCertErr x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnectionRefused
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownAuthErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCertErr):
+		return ErrorClassTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	default:
+		return ErrorClassConnection
+	}
+}
@@ -0,0 +1,261 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+const TypeDNS = "dns"
+
+// Record types supported by the dns monitors.
+var RecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT", "SRV"}
+
+// How the answers of the dns monitors are compared with the expected values.
+const (
+	MatchContains = "contains" // every expected value is in the answers
+	MatchEquals   = "equals"   // the answers are exactly the expected values
+)
+
+// DNSConfig is the config of the dns monitors.
+type DNSConfig struct {
+	TimeoutConfig
+	// Resolver is the host:port of the DNS server queried, the port defaults to 53. The system
+	// resolver is used when it is empty.
+	Resolver   string `json:"resolver,omitempty"`
+	RecordType string `json:"record_type"`
+	Match      string `json:"match"`
+	// Expected are the values asserted on the answers, written like A: 192.0.2.1, CNAME:
+	// www.example.com, MX: "10 mail.example.com", SRV: "10 5 5060 sip.example.com". Without them
+	// the check only verifies that the name resolves.
+	Expected []string `json:"expected,omitempty"`
+}
+
+// DNSChecker resolves the URL of the monitor, that is a domain name, and asserts its answers.
+type DNSChecker struct {
+	Timeout time.Duration
+	// Resolver is used by the monitors without a resolver in the config.
+	Resolver *net.Resolver
+}
+
+func NewDNSChecker(timeout time.Duration) *DNSChecker {
+	return &DNSChecker{Timeout: timeout, Resolver: net.DefaultResolver}
+}
+
+// dnsConfig decodes the config of the monitor, filling the defaults.
+func dnsConfig(monitor data.Monitor) (DNSConfig, error) {
+	config := DNSConfig{RecordType: "A", Match: MatchContains}
+	err := decodeConfig(monitor.Config, &config)
+	config.RecordType = strings.ToUpper(config.RecordType)
+	return config, err
+}
+
+// resolverAddress returns the resolver address with the default port.
+func resolverAddress(resolver string) (string, error) {
+	if _, _, err := net.SplitHostPort(resolver); err == nil {
+		return resolver, nil
+	}
+	if net.ParseIP(strings.Trim(resolver, "[]")) == nil && strings.Contains(resolver, ":") {
+		return "", fmt.Errorf("invalid resolver %q, must be host or host:port", resolver)
+	}
+	return net.JoinHostPort(strings.Trim(resolver, "[]"), "53"), nil
+}
+
+func (c *DNSChecker) Validate(monitor data.Monitor) error {
+	v := validator.New()
+	config, err := dnsConfig(monitor)
+	if err != nil {
+		v.AddError("config", err.Error())
+	}
+	config.validateTimeout(v)
+	v.Check(validator.PermittedValue(config.RecordType, RecordTypes...), "config", "record_type must be one of "+strings.Join(RecordTypes, ", "))
+	v.Check(validator.PermittedValue(config.Match, MatchContains, MatchEquals), "config", "match must be contains or equals")
+	if config.Resolver != "" {
+		_, err := resolverAddress(config.Resolver)
+		v.Check(err == nil, "config", "resolver must be a host or host:port address")
+	}
+	for _, expected := range config.Expected {
+		_, err := normalizeAnswer(config.RecordType, expected)
+		v.Check(err == nil, "config", fmt.Sprintf("expected value %q is not a valid %s record", expected, config.RecordType))
+	}
+	v.Check(len(monitor.URL) <= 253 && !strings.ContainsAny(monitor.URL, "/: "), "url", "must be a domain name")
+	checkHTTPFieldsUnused(v, monitor, TypeDNS)
+	if !v.Valid() {
+		return &data.ValidationError{Fields: v.Errors}
+	}
+	return nil
+}
+
+func (c *DNSChecker) Check(ctx context.Context, monitor data.Monitor) Result {
+	result := Result{MonitorID: monitor.MonitorID, CheckedAt: time.Now().UTC()}
+
+	config, err := dnsConfig(monitor)
+	if err != nil {
+		result.fail(ErrorClassInvalidMonitor, err)
+		return result
+	}
+	expected := make([]string, 0, len(config.Expected))
+	for _, value := range config.Expected {
+		answer, err := normalizeAnswer(config.RecordType, value)
+		if err != nil {
+			result.fail(ErrorClassInvalidMonitor, err)
+			return result
+		}
+		expected = append(expected, answer)
+	}
+	resolver := c.Resolver
+	if config.Resolver != "" {
+		address, err := resolverAddress(config.Resolver)
+		if err != nil {
+			result.fail(ErrorClassInvalidMonitor, err)
+			return result
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, address)
+			},
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.timeout(c.Timeout))
+	defer cancel()
+
+	//the name is made fully qualified, so the search domains of the host are not used
+	name := monitor.URL
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	start := time.Now()
+	answers, err := lookupRecords(ctx, resolver, config.RecordType, name)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.fail(classifyError(err), err)
+		return result
+	}
+	if len(expected) > 0 && !matchAnswers(config.Match, expected, answers) {
+		sort.Strings(expected)
+		sort.Strings(answers)
+		result.failAssertion(ErrorClassAssertion, fmt.Sprintf("answers %s %q", config.Match, expected), fmt.Sprintf("%q", answers))
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+// lookupRecords returns the answers of the record type, formatted like the expected values.
+func lookupRecords(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, normalizeName(cname))
+	case "MX":
+		records, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range records {
+			answers = append(answers, fmt.Sprintf("%d %s", mx.Pref, normalizeName(mx.Host)))
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, records...)
+	case "SRV":
+		_, records, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range records {
+			answers = append(answers, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, normalizeName(srv.Target)))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+	return answers, nil
+}
+
+// normalizeAnswer rewrites an expected value in the format returned by lookupRecords, so they can
+// be compared as strings.
+func normalizeAnswer(recordType, value string) (string, error) {
+	invalid := fmt.Errorf("invalid %s record %q", recordType, value)
+	switch recordType {
+	case "A", "AAAA":
+		ip := net.ParseIP(value)
+		if ip == nil || (ip.To4() != nil) != (recordType == "A") {
+			return "", invalid
+		}
+		return ip.String(), nil
+	case "CNAME":
+		if value == "" || strings.ContainsAny(value, " /:") {
+			return "", invalid
+		}
+		return normalizeName(value), nil
+	case "MX", "SRV":
+		fields := strings.Fields(value)
+		numbers := 1
+		if recordType == "SRV" {
+			numbers = 3
+		}
+		if len(fields) != numbers+1 {
+			return "", invalid
+		}
+		for _, field := range fields[:numbers] {
+			if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+				return "", invalid
+			}
+		}
+		fields[numbers] = normalizeName(fields[numbers])
+		return strings.Join(fields, " "), nil
+	case "TXT":
+		return value, nil
+	default:
+		return "", fmt.Errorf("unsupported record type %q", recordType)
+	}
+}
+
+// normalizeName lowercases a domain name and removes the trailing dot.
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// matchAnswers verifies the answers against the expected values.
+func matchAnswers(match string, expected, answers []string) bool {
+	got := map[string]bool{}
+	for _, answer := range answers {
+		got[answer] = true
+	}
+	want := map[string]bool{}
+	for _, value := range expected {
+		if !got[value] {
+			return false
+		}
+		want[value] = true
+	}
+	return match != MatchEquals || len(got) == len(want)
+}
@@ -0,0 +1,260 @@
+package checker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+// DNS types used by the stub server.
+const (
+	dnsTypeA     = 1
+	dnsTypeCNAME = 5
+	dnsTypeMX    = 15
+	dnsTypeTXT   = 16
+	dnsTypeAAAA  = 28
+	dnsTypeSRV   = 33
+)
+
+type stubRecord struct {
+	name  string
+	qtype uint16
+	rdata []byte
+}
+
+// stubDNSServer answers the UDP queries with the records whose name and type match the question,
+// and with NXDOMAIN when no record has the name. It understands just enough of RFC 1035 to serve
+// the Go resolver.
+type stubDNSServer struct {
+	conn    net.PacketConn
+	records []stubRecord
+}
+
+func newStubDNSServer(t *testing.T, records ...stubRecord) *stubDNSServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stubDNSServer{conn: conn, records: records}
+	go s.serve()
+	t.Cleanup(func() { conn.Close() })
+	return s
+}
+
+func (s *stubDNSServer) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *stubDNSServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *stubDNSServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	//read the name of the question, that starts after the header
+	var labels []string
+	offset := 12
+	for offset < len(query) && query[offset] != 0 {
+		length := int(query[offset])
+		if offset+1+length > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[offset+1:offset+1+length]))
+		offset += 1 + length
+	}
+	offset++
+	if offset+4 > len(query) {
+		return nil
+	}
+	name := strings.ToLower(strings.Join(labels, "."))
+	qtype := binary.BigEndian.Uint16(query[offset:])
+	question := query[12 : offset+4]
+
+	var answers []stubRecord
+	known := false
+	for _, record := range s.records {
+		if record.name == name {
+			known = true
+			if record.qtype == qtype {
+				answers = append(answers, record)
+			}
+		}
+	}
+	//flags: response, same opcode and recursion desired, authoritative, recursion available
+	flags := 0x8000 | binary.BigEndian.Uint16(query[2:])&0x7900 | 0x0400 | 0x0080
+	if !known {
+		flags |= 3 // NXDOMAIN
+	}
+	resp := binary.BigEndian.AppendUint16(nil, binary.BigEndian.Uint16(query))
+	resp = binary.BigEndian.AppendUint16(resp, flags)
+	resp = binary.BigEndian.AppendUint16(resp, 1)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(answers)))
+	resp = binary.BigEndian.AppendUint16(resp, 0)
+	resp = binary.BigEndian.AppendUint16(resp, 0)
+	resp = append(resp, question...)
+	for _, record := range answers {
+		resp = binary.BigEndian.AppendUint16(resp, 0xC00C) // pointer to the question name
+		resp = binary.BigEndian.AppendUint16(resp, record.qtype)
+		resp = binary.BigEndian.AppendUint16(resp, 1) // class IN
+		resp = binary.BigEndian.AppendUint32(resp, 60)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(record.rdata)))
+		resp = append(resp, record.rdata...)
+	}
+	return resp
+}
+
+func encodeDNSName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func recordA(name, ip string) stubRecord {
+	return stubRecord{name: name, qtype: dnsTypeA, rdata: net.ParseIP(ip).To4()}
+}
+
+func recordAAAA(name, ip string) stubRecord {
+	return stubRecord{name: name, qtype: dnsTypeAAAA, rdata: net.ParseIP(ip).To16()}
+}
+
+func recordCNAME(name, target string) stubRecord {
+	return stubRecord{name: name, qtype: dnsTypeCNAME, rdata: encodeDNSName(target)}
+}
+
+func recordMX(name string, pref uint16, host string) stubRecord {
+	return stubRecord{name: name, qtype: dnsTypeMX, rdata: append(binary.BigEndian.AppendUint16(nil, pref), encodeDNSName(host)...)}
+}
+
+func recordTXT(name, text string) stubRecord {
+	return stubRecord{name: name, qtype: dnsTypeTXT, rdata: append([]byte{byte(len(text))}, text...)}
+}
+
+func recordSRV(name string, priority, weight, port uint16, target string) stubRecord {
+	rdata := binary.BigEndian.AppendUint16(nil, priority)
+	rdata = binary.BigEndian.AppendUint16(rdata, weight)
+	rdata = binary.BigEndian.AppendUint16(rdata, port)
+	return stubRecord{name: name, qtype: dnsTypeSRV, rdata: append(rdata, encodeDNSName(target)...)}
+}
+
+func dnsMonitor(name string, config DNSConfig) data.Monitor {
+	raw, _ := json.Marshal(config)
+	return data.Monitor{MonitorType: TypeDNS, URL: name, Config: raw}
+}
+
+func TestDNSChecker_Check(t *testing.T) {
+	srv := newStubDNSServer(t,
+		recordA("api.simplemon.test", "192.0.2.1"),
+		recordA("api.simplemon.test", "192.0.2.2"),
+		recordAAAA("api.simplemon.test", "2001:db8::1"),
+		recordCNAME("www.simplemon.test", "api.simplemon.test"),
+		recordMX("simplemon.test", 10, "mail.simplemon.test"),
+		recordTXT("simplemon.test", "v=spf1 -all"),
+		recordSRV("_sip._tcp.simplemon.test", 10, 5, 5060, "sip.simplemon.test"),
+	)
+
+	tests := []struct {
+		name       string
+		monitor    data.Monitor
+		success    bool
+		errorClass ErrorClass
+	}{
+		{
+			name:    "Test Check A contains",
+			monitor: dnsMonitor("api.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "A", Match: MatchContains, Expected: []string{"192.0.2.2"}}),
+			success: true,
+		},
+		{
+			name:    "Test Check A equals",
+			monitor: dnsMonitor("api.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "a", Match: MatchEquals, Expected: []string{"192.0.2.2", "192.0.2.1"}}),
+			success: true,
+		},
+		{
+			name:       "Test Check A equals with a missing answer",
+			monitor:    dnsMonitor("api.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "A", Match: MatchEquals, Expected: []string{"192.0.2.1"}}),
+			errorClass: ErrorClassAssertion,
+		},
+		{
+			name:       "Test Check A drift",
+			monitor:    dnsMonitor("api.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "A", Match: MatchContains, Expected: []string{"192.0.2.3"}}),
+			errorClass: ErrorClassAssertion,
+		},
+		{
+			name:    "Test Check AAAA",
+			monitor: dnsMonitor("api.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "AAAA", Match: MatchEquals, Expected: []string{"2001:DB8:0::1"}}),
+			success: true,
+		},
+		{
+			name:    "Test Check CNAME",
+			monitor: dnsMonitor("www.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "CNAME", Match: MatchEquals, Expected: []string{"API.simplemon.test."}}),
+			success: true,
+		},
+		{
+			name:    "Test Check MX",
+			monitor: dnsMonitor("simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "MX", Match: MatchEquals, Expected: []string{"10 mail.simplemon.test"}}),
+			success: true,
+		},
+		{
+			name:    "Test Check TXT",
+			monitor: dnsMonitor("simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "TXT", Match: MatchContains, Expected: []string{"v=spf1 -all"}}),
+			success: true,
+		},
+		{
+			name:    "Test Check SRV",
+			monitor: dnsMonitor("_sip._tcp.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "SRV", Match: MatchEquals, Expected: []string{"10 5 5060 sip.simplemon.test"}}),
+			success: true,
+		},
+		{
+			name:    "Test Check resolves without expected values",
+			monitor: dnsMonitor("api.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "A", Match: MatchContains}),
+			success: true,
+		},
+		{
+			name:       "Test Check unknown name",
+			monitor:    dnsMonitor("jojo.simplemon.test", DNSConfig{Resolver: srv.addr(), RecordType: "A", Match: MatchContains}),
+			errorClass: ErrorClassDNS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDNSChecker(time.Second)
+			result := c.Check(context.Background(), tt.monitor)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			assert.Equal(t, tt.errorClass, result.ErrorClass)
+		})
+	}
+}
+
+func TestDNSChecker_Validate(t *testing.T) {
+	c := NewDNSChecker(0)
+	assert.NoError(t, c.Validate(data.Monitor{URL: "www.google.com"}))
+	assert.NoError(t, c.Validate(dnsMonitor("google.com", DNSConfig{Resolver: "8.8.8.8", RecordType: "MX", Match: MatchEquals, Expected: []string{"10 smtp.google.com"}})))
+	assert.NoError(t, c.Validate(dnsMonitor("google.com", DNSConfig{Resolver: "[2001:4860:4860::8888]:53", RecordType: "A", Match: MatchContains})))
+	assert.Error(t, c.Validate(data.Monitor{URL: "https://www.google.com"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "www.google.com", Method: "GET"}))
+	assert.Error(t, c.Validate(dnsMonitor("google.com", DNSConfig{RecordType: "NS", Match: MatchContains})))
+	assert.Error(t, c.Validate(dnsMonitor("google.com", DNSConfig{TimeoutConfig: TimeoutConfig{TimeoutSeconds: -1}, RecordType: "A", Match: MatchContains})))
+	assert.Error(t, c.Validate(dnsMonitor("google.com", DNSConfig{RecordType: "A", Match: "like"})))
+	assert.Error(t, c.Validate(dnsMonitor("google.com", DNSConfig{RecordType: "A", Match: MatchContains, Expected: []string{"2001:db8::1"}})))
+	assert.Error(t, c.Validate(dnsMonitor("google.com", DNSConfig{RecordType: "MX", Match: MatchContains, Expected: []string{"smtp.google.com"}})))
+}
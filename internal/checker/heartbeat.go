@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/rs/zerolog"
+)
+
+const TypeHeartbeat = "heartbeat"
+
+// PingPath is the path of the ping URL of the heartbeat monitors, followed by the token.
+const PingPath = "/v1/ping/"
+
+// heartbeatInterval is how often the heartbeat monitors are checked, regardless of their
+// FrequencyMinutes, so a missing ping is noticed in about a minute.
+const heartbeatInterval = time.Minute
+
+var pingURLRX = regexp.MustCompile(`^/v1/ping/[A-Za-z0-9_-]{22,128}$`)
+
+// HeartbeatConfig is the config of the heartbeat monitors, that do not have any setting.
+type HeartbeatConfig struct{}
+
+// HeartbeatChecker does not probe anything: the monitored job pings the URL of the monitor, that
+// is /v1/ping/<token>, and the check fails when the last ping reported a failure or when no ping
+// was received within FrequencyMinutes. The ThresholdMinutes of the monitor is the grace period
+// before the incident is opened, like for the other types.
+type HeartbeatChecker struct {
+	Pings data.PingInterface
+}
+
+func NewHeartbeatChecker(pings data.PingInterface) *HeartbeatChecker {
+	return &HeartbeatChecker{Pings: pings}
+}
+
+// PingToken returns the token of a ping URL.
+func PingToken(url string) string {
+	return strings.TrimPrefix(url, PingPath)
+}
+
+// Prepare generates the ping URL of the monitors created without one.
+func (c *HeartbeatChecker) Prepare(monitor *data.Monitor) error {
+	if monitor.URL != "" {
+		return nil
+	}
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	monitor.URL = PingPath + base64.RawURLEncoding.EncodeToString(token)
+	return nil
+}
+
+// Interval checks the heartbeat monitors every minute.
+func (c *HeartbeatChecker) Interval(monitor data.Monitor) time.Duration {
+	return heartbeatInterval
+}
+
+func (c *HeartbeatChecker) Validate(monitor data.Monitor) error {
+	v := validator.New()
+	var config HeartbeatConfig
+	if err := decodeConfig(monitor.Config, &config); err != nil {
+		v.AddError("config", err.Error())
+	}
+	v.Check(validator.Matches(monitor.URL, pingURLRX), "url", "must be empty or /v1/ping/ followed by a token of at least 22 letters, digits, _ or -")
+	checkHTTPFieldsUnused(v, monitor, TypeHeartbeat)
+	if !v.Valid() {
+		return &data.ValidationError{Fields: v.Errors}
+	}
+	return nil
+}
+
+func (c *HeartbeatChecker) Check(ctx context.Context, monitor data.Monitor) Result {
+	result := Result{MonitorID: monitor.MonitorID, CheckedAt: time.Now().UTC()}
+
+	last, err := c.Pings.GetLast(ctx, monitor.MonitorID, zerolog.Nop())
+	if err != nil {
+		result.fail(ErrorClassInvalidMonitor, fmt.Errorf("error getting the pings: %w", err))
+		return result
+	}
+	finished := last.Finished()
+	if finished != nil && finished.DurationMs != nil {
+		result.Latency = time.Duration(*finished.DurationMs) * time.Millisecond
+	}
+	if finished != nil && finished.Kind == data.PingFail {
+		err := errors.New("the job reported a failure")
+		if finished.Message != "" {
+			err = fmt.Errorf("the job reported a failure: %s", finished.Message)
+		}
+		result.fail(ErrorClassHeartbeatFailed, err)
+		return result
+	}
+
+	//the period restarts when the monitor is changed, so a new monitor is not late right away
+	since := monitor.UpdatedAt
+	if last.Success != nil && last.Success.ReceivedAt.After(since) {
+		since = last.Success.ReceivedAt
+	}
+	period := time.Duration(monitor.FrequencyMinutes) * time.Minute
+	if result.CheckedAt.Sub(since) > period {
+		result.fail(ErrorClassHeartbeatMissed, fmt.Errorf("no ping received since %s", since.UTC().Format(time.RFC3339)))
+		return result
+	}
+	result.Success = true
+	return result
+}
@@ -0,0 +1,138 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHeartbeatChecker_Check(t *testing.T) {
+	now := time.Now().UTC()
+	duration := int64(90000)
+	monitor := data.Monitor{MonitorID: 1, MonitorType: TypeHeartbeat, FrequencyMinutes: 60, UpdatedAt: now.Add(-48 * time.Hour)}
+
+	tests := []struct {
+		name       string
+		monitor    data.Monitor
+		last       *data.LastPings
+		err        error
+		success    bool
+		latency    time.Duration
+		errorClass ErrorClass
+		message    string
+	}{
+		{
+			name:    "Test Check ping received within the frequency",
+			monitor: monitor,
+			last:    &data.LastPings{Success: &data.Ping{Kind: data.PingSuccess, ReceivedAt: now.Add(-30 * time.Minute)}},
+			success: true,
+		},
+		{
+			name:       "Test Check ping missed",
+			monitor:    monitor,
+			last:       &data.LastPings{Success: &data.Ping{Kind: data.PingSuccess, ReceivedAt: now.Add(-61 * time.Minute)}},
+			errorClass: ErrorClassHeartbeatMissed,
+			message:    "no ping received since",
+		},
+		{
+			name:    "Test Check new monitor without pings",
+			monitor: data.Monitor{MonitorID: 1, FrequencyMinutes: 60, UpdatedAt: now.Add(-time.Minute)},
+			last:    &data.LastPings{},
+			success: true,
+		},
+		{
+			name:       "Test Check monitor never pinged",
+			monitor:    monitor,
+			last:       &data.LastPings{},
+			errorClass: ErrorClassHeartbeatMissed,
+		},
+		{
+			name:    "Test Check job duration",
+			monitor: monitor,
+			last: &data.LastPings{
+				Start:   &data.Ping{Kind: data.PingStart, ReceivedAt: now.Add(-5 * time.Minute)},
+				Success: &data.Ping{Kind: data.PingSuccess, ReceivedAt: now.Add(-3 * time.Minute), DurationMs: &duration},
+			},
+			success: true,
+			latency: 90 * time.Second,
+		},
+		{
+			name:    "Test Check job started but not finished yet",
+			monitor: monitor,
+			last: &data.LastPings{
+				Start:   &data.Ping{Kind: data.PingStart, ReceivedAt: now.Add(-time.Minute)},
+				Success: &data.Ping{Kind: data.PingSuccess, ReceivedAt: now.Add(-50 * time.Minute)},
+			},
+			success: true,
+		},
+		{
+			name:    "Test Check job reported a failure",
+			monitor: monitor,
+			last: &data.LastPings{
+				Success: &data.Ping{Kind: data.PingSuccess, ReceivedAt: now.Add(-50 * time.Minute)},
+				Fail:    &data.Ping{Kind: data.PingFail, ReceivedAt: now.Add(-time.Minute), Message: "disk full"},
+			},
+			errorClass: ErrorClassHeartbeatFailed,
+			message:    "the job reported a failure: disk full",
+		},
+		{
+			name:    "Test Check job succeeded after a failure",
+			monitor: monitor,
+			last: &data.LastPings{
+				Success: &data.Ping{Kind: data.PingSuccess, ReceivedAt: now.Add(-time.Minute)},
+				Fail:    &data.Ping{Kind: data.PingFail, ReceivedAt: now.Add(-50 * time.Minute)},
+			},
+			success: true,
+		},
+		{
+			name:       "Test Check database error",
+			monitor:    monitor,
+			err:        errors.New("database generic error"),
+			errorClass: ErrorClassInvalidMonitor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pings := data.NewPingModelMock()
+			pings.On("GetLast", mock.Anything, int64(1), mock.Anything).Return(tt.last, tt.err)
+			c := NewHeartbeatChecker(pings)
+			result := c.Check(context.Background(), tt.monitor)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			assert.Equal(t, tt.errorClass, result.ErrorClass)
+			assert.Equal(t, tt.latency, result.Latency)
+			assert.Contains(t, result.Error, tt.message)
+		})
+	}
+}
+
+func TestHeartbeatChecker_Prepare(t *testing.T) {
+	c := NewHeartbeatChecker(nil)
+	monitor := data.Monitor{MonitorType: TypeHeartbeat}
+	assert.NoError(t, c.Prepare(&monitor))
+	assert.True(t, strings.HasPrefix(monitor.URL, PingPath))
+	assert.NoError(t, c.Validate(monitor))
+
+	other := data.Monitor{MonitorType: TypeHeartbeat}
+	assert.NoError(t, c.Prepare(&other))
+	assert.NotEqual(t, monitor.URL, other.URL, "tokens must be random")
+
+	//the url of an existing monitor is kept
+	url := monitor.URL
+	assert.NoError(t, c.Prepare(&monitor))
+	assert.Equal(t, url, monitor.URL)
+}
+
+func TestHeartbeatChecker_Validate(t *testing.T) {
+	c := NewHeartbeatChecker(nil)
+	assert.NoError(t, c.Validate(data.Monitor{URL: "/v1/ping/0123456789abcdefghij_-"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "/v1/ping/short"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "https://www.google.com"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "/v1/ping/0123456789abcdefghij_-", Method: "GET"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "/v1/ping/0123456789abcdefghij_-", Config: []byte(`{"grace": 5}`)}))
+}
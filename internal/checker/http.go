@@ -0,0 +1,181 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+const TypeHTTP = "http"
+
+// HTTPConfig is the config of the http monitors.
+type HTTPConfig struct {
+	TimeoutConfig
+	// Assertions are verified in order on the response, the first one that fails is recorded.
+	Assertions []Assertion `json:"assertions,omitempty"`
+}
+
+// maxAssertedBodyBytes is the max number of bytes of the response body read for the assertions.
+const maxAssertedBodyBytes = 1 << 20
+
+// HTTPChecker performs the request described by the URL, Method, Body, Headers and Parameters
+// fields of a monitor. The check succeeds when a response with a status code lower than 400 is
+// received, or one of the expected status codes if the monitor has a status_code assertion, and
+// all the assertions of the monitor pass.
+type HTTPChecker struct {
+	Client *http.Client
+}
+
+func NewHTTPChecker(timeout time.Duration) *HTTPChecker {
+	return &HTTPChecker{Client: &http.Client{Timeout: timeout}}
+}
+
+// NewRequest builds the http request described by the monitor.
+func NewRequest(ctx context.Context, monitor data.Monitor) (*http.Request, error) {
+	headers, err := monitor.HeaderMap()
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := monitor.ParameterMap()
+	if err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(monitor.URL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", target.Scheme)
+	}
+	query := target.Query()
+	for key, value := range parameters {
+		query.Set(key, value)
+	}
+	target.RawQuery = query.Encode()
+
+	var body io.Reader
+	if monitor.Body != "" {
+		body = strings.NewReader(monitor.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(monitor.Method), target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// Methods is the list of http methods accepted in the Method field of the http monitors.
+var Methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+func (c *HTTPChecker) Validate(monitor data.Monitor) error {
+	v := validator.New()
+	var config HTTPConfig
+	if err := decodeConfig(monitor.Config, &config); err != nil {
+		v.AddError("config", err.Error())
+	}
+	config.validateTimeout(v)
+	v.Check(len(config.Assertions) <= MaxAssertions, "config", fmt.Sprintf("must not have more than %d assertions", MaxAssertions))
+	for i, assertion := range config.Assertions {
+		if err := assertion.validate(); err != nil {
+			v.AddError("config", fmt.Sprintf("assertions[%d]: %v", i, err))
+		}
+	}
+	if _, err := monitor.HeaderMap(); err != nil {
+		v.AddError("headers", data.ErrInvalidHeaders.Fields["headers"])
+	}
+	if _, err := monitor.ParameterMap(); err != nil {
+		v.AddError("parameters", data.ErrInvalidParameters.Fields["parameters"])
+	}
+	target, err := url.Parse(monitor.URL)
+	v.Check(err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != "", "url", "must be an absolute http or https url")
+	v.Check(monitor.Method != "", "method", "is required")
+	v.Check(validator.PermittedValue(strings.ToUpper(monitor.Method), Methods...), "method", "must be one of "+strings.Join(Methods, ", "))
+	if !v.Valid() {
+		return &data.ValidationError{Fields: v.Errors}
+	}
+	return nil
+}
+
+func (c *HTTPChecker) Check(ctx context.Context, monitor data.Monitor) Result {
+	result := Result{MonitorID: monitor.MonitorID, CheckedAt: time.Now().UTC()}
+
+	var config HTTPConfig
+	if err := decodeConfig(monitor.Config, &config); err != nil {
+		result.fail(ErrorClassInvalidMonitor, err)
+		return result
+	}
+	client := c.Client
+	if config.TimeoutSeconds > 0 {
+		custom := *c.Client
+		custom.Timeout = config.timeout(c.Client.Timeout)
+		client = &custom
+	}
+	req, err := NewRequest(ctx, monitor)
+	if err != nil {
+		result.fail(ErrorClassInvalidMonitor, err)
+		return result
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Latency = time.Since(start)
+		result.fail(classifyError(err), err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		result.recordCertificate(resp.TLS.PeerCertificates[0])
+	}
+	//only the beginning of the body is kept for the assertions, the rest is just counted
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAssertedBodyBytes))
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(io.Discard, resp.Body)
+		result.ResponseSize = int64(len(body)) + rest
+	}
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.fail(classifyError(err), err)
+		return result
+	}
+
+	hasStatusAssertion := false
+	for _, assertion := range config.Assertions {
+		hasStatusAssertion = hasStatusAssertion || assertion.Type == AssertStatusCode
+	}
+	if !hasStatusAssertion && resp.StatusCode >= http.StatusBadRequest {
+		result.fail(ErrorClassHTTPStatus, fmt.Errorf("unexpected status code %d", resp.StatusCode))
+		return result
+	}
+	for _, assertion := range config.Assertions {
+		actual, ok := assertion.check(resp, body, result.Latency)
+		if ok {
+			continue
+		}
+		class := ErrorClassAssertion
+		if assertion.Type == AssertStatusCode {
+			class = ErrorClassHTTPStatus
+		}
+		result.failAssertion(class, assertion.String(), actual)
+		return result
+	}
+	result.Success = true
+	return result
+}
@@ -0,0 +1,101 @@
+package checker
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPChecker_Check(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != http.MethodPost || string(body) != `{"ping":true}` ||
+				r.Header.Get("X-Token") != "secret" || r.URL.Query().Get("page") != "2" || r.URL.Query().Get("q") != "a" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("pong"))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	//get an address that refuses connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name         string
+		monitor      data.Monitor
+		success      bool
+		statusCode   int
+		responseSize int64
+		errorClass   ErrorClass
+	}{
+		{
+			name: "Test Check with method, body, headers and parameters",
+			monitor: data.Monitor{
+				URL:        srv.URL + "/echo?q=a",
+				Method:     "post",
+				Body:       `{"ping":true}`,
+				Headers:    `{"X-Token": "secret"}`,
+				Parameters: `{"page": "2"}`,
+			},
+			success:      true,
+			statusCode:   200,
+			responseSize: 4,
+		},
+		{
+			name:       "Test Check error status code",
+			monitor:    data.Monitor{URL: srv.URL + "/error", Method: "GET"},
+			statusCode: 500,
+			errorClass: ErrorClassHTTPStatus,
+		},
+		{
+			name:       "Test Check invalid headers",
+			monitor:    data.Monitor{URL: srv.URL, Method: "GET", Headers: "X-Token: secret"},
+			errorClass: ErrorClassInvalidMonitor,
+		},
+		{
+			name:       "Test Check unsupported scheme",
+			monitor:    data.Monitor{URL: "ftp://localhost", Method: "GET"},
+			errorClass: ErrorClassInvalidMonitor,
+		},
+		{
+			name:       "Test Check connection refused",
+			monitor:    data.Monitor{URL: "http://" + closedAddr, Method: "GET"},
+			errorClass: ErrorClassConnectionRefused,
+		},
+		{
+			name:       "Test Check timeout",
+			monitor:    data.Monitor{URL: srv.URL + "/slow", Method: "GET"},
+			errorClass: ErrorClassTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewHTTPChecker(100 * time.Millisecond)
+			result := c.Check(context.Background(), tt.monitor)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			assert.Equal(t, tt.statusCode, result.StatusCode)
+			assert.Equal(t, tt.responseSize, result.ResponseSize)
+			assert.Equal(t, tt.errorClass, result.ErrorClass)
+		})
+	}
+}
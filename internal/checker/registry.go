@@ -0,0 +1,146 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+// Checker is implemented by each monitor type. To add a new type, implement this interface and
+// register it in NewDefaultRegistry, the handlers and the scheduler use the registry to validate
+// and check the monitors of any type.
+type Checker interface {
+	// Validate verifies the fields of the monitor used by this type, including its Config.
+	Validate(monitor data.Monitor) error
+	// Check executes the check described by the monitor.
+	Check(ctx context.Context, monitor data.Monitor) Result
+}
+
+// Preparer is implemented by the types that fill some fields of the monitor before it is validated
+// and stored, like a generated URL.
+type Preparer interface {
+	Prepare(monitor *data.Monitor) error
+}
+
+// Intervaler is implemented by the types that are not checked every FrequencyMinutes.
+type Intervaler interface {
+	Interval(monitor data.Monitor) time.Duration
+}
+
+// UnknownTypeError is returned when a monitor has a type that is not registered.
+type UnknownTypeError struct {
+	Type  string
+	Known []string
+}
+
+func (e *UnknownTypeError) Error() string {
+	return fmt.Sprintf("unknown monitor type %q, must be one of %v", e.Type, e.Known)
+}
+
+type Registry struct {
+	checkers map[string]Checker
+}
+
+func NewRegistry() *Registry {
+	return &Registry{checkers: make(map[string]Checker)}
+}
+
+// NewDefaultRegistry returns a registry with all the monitor types built in simplemon. The pings
+// are used by the heartbeat monitors.
+func NewDefaultRegistry(timeout time.Duration, pings data.PingInterface) *Registry {
+	r := NewRegistry()
+	r.Register(TypeHTTP, NewHTTPChecker(timeout))
+	r.Register(TypeTCP, NewTCPChecker(timeout))
+	r.Register(TypeTLS, NewTLSChecker(timeout))
+	r.Register(TypeDNS, NewDNSChecker(timeout))
+	r.Register(TypeHeartbeat, NewHeartbeatChecker(pings))
+	return r
+}
+
+// Register adds a monitor type, replacing the checker if the type was already registered.
+func (r *Registry) Register(monitorType string, checker Checker) {
+	r.checkers[monitorType] = checker
+}
+
+func (r *Registry) Lookup(monitorType string) (Checker, bool) {
+	checker, ok := r.checkers[monitorType]
+	return checker, ok
+}
+
+// Types returns the registered monitor types in alphabetical order.
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.checkers))
+	for monitorType := range r.checkers {
+		types = append(types, monitorType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate verifies that the type of the monitor is registered and that the monitor is valid for
+// that type.
+func (r *Registry) Validate(monitor data.Monitor) error {
+	checker, ok := r.Lookup(monitor.MonitorType)
+	if !ok {
+		return &UnknownTypeError{Type: monitor.MonitorType, Known: r.Types()}
+	}
+	return checker.Validate(monitor)
+}
+
+// Prepare fills the fields generated by the type of the monitor, if its checker is a Preparer.
+func (r *Registry) Prepare(monitor *data.Monitor) error {
+	if preparer, ok := r.checkers[monitor.MonitorType].(Preparer); ok {
+		return preparer.Prepare(monitor)
+	}
+	return nil
+}
+
+// Interval returns how often the monitor must be checked if its checker is an Intervaler, and 0
+// to use the FrequencyMinutes of the monitor.
+func (r *Registry) Interval(monitor data.Monitor) time.Duration {
+	if intervaler, ok := r.checkers[monitor.MonitorType].(Intervaler); ok {
+		return intervaler.Interval(monitor)
+	}
+	return 0
+}
+
+// Check executes the monitor using the checker of its type.
+func (r *Registry) Check(ctx context.Context, monitor data.Monitor) Result {
+	checker, ok := r.Lookup(monitor.MonitorType)
+	if !ok {
+		result := Result{MonitorID: monitor.MonitorID, CheckedAt: time.Now().UTC()}
+		result.fail(ErrorClassInvalidMonitor, &UnknownTypeError{Type: monitor.MonitorType, Known: r.Types()})
+		return result
+	}
+	return checker.Check(ctx, monitor)
+}
+
+// checkHTTPFieldsUnused adds an error for each of the Method, Body, Headers and Parameters fields
+// set in a monitor of a type that does not use them.
+func checkHTTPFieldsUnused(v *validator.Validator, monitor data.Monitor, monitorType string) {
+	message := "is not supported by " + monitorType + " monitors"
+	v.Check(monitor.Method == "", "method", message)
+	v.Check(monitor.Body == "", "body", message)
+	v.Check(monitor.Headers == "", "headers", message)
+	v.Check(monitor.Parameters == "", "parameters", message)
+}
+
+// decodeConfig decodes the config of a monitor into the config struct of its type, rejecting
+// unknown fields. An empty config leaves the struct untouched, so it can be filled with defaults.
+func decodeConfig(raw json.RawMessage, config interface{}) error {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(config); err != nil {
+		return fmt.Errorf("invalid config: %v", err)
+	}
+	return nil
+}
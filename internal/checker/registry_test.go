@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChecker struct {
+	validateErr error
+}
+
+func (c fakeChecker) Validate(monitor data.Monitor) error {
+	return c.validateErr
+}
+
+func (c fakeChecker) Check(ctx context.Context, monitor data.Monitor) Result {
+	return Result{MonitorID: monitor.MonitorID, Success: true}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("fake", fakeChecker{})
+	r.Register("broken", fakeChecker{validateErr: errors.New("broken")})
+	assert.Equal(t, []string{"broken", "fake"}, r.Types())
+
+	assert.NoError(t, r.Validate(data.Monitor{MonitorType: "fake"}))
+	assert.EqualError(t, r.Validate(data.Monitor{MonitorType: "broken"}), "broken")
+
+	var unknown *UnknownTypeError
+	err := r.Validate(data.Monitor{MonitorType: "jojo"})
+	if assert.ErrorAs(t, err, &unknown) {
+		assert.Equal(t, "jojo", unknown.Type)
+	}
+
+	assert.NoError(t, r.Prepare(&data.Monitor{MonitorType: "fake"}))
+	assert.Equal(t, time.Duration(0), r.Interval(data.Monitor{MonitorType: "fake"}))
+
+	result := r.Check(context.Background(), data.Monitor{MonitorID: 1, MonitorType: "fake"})
+	assert.True(t, result.Success)
+	result = r.Check(context.Background(), data.Monitor{MonitorID: 1, MonitorType: "jojo"})
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrorClassInvalidMonitor, result.ErrorClass)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	r := NewDefaultRegistry(time.Second, data.NewPingModelMock())
+	assert.Equal(t, []string{TypeDNS, TypeHeartbeat, TypeHTTP, TypeTCP, TypeTLS}, r.Types())
+	assert.Equal(t, time.Minute, r.Interval(data.Monitor{MonitorType: TypeHeartbeat}))
+
+	monitor := data.Monitor{MonitorType: TypeHeartbeat}
+	assert.NoError(t, r.Prepare(&monitor))
+	assert.NotEmpty(t, monitor.URL)
+}
+
+func TestHTTPChecker_Validate(t *testing.T) {
+	c := NewHTTPChecker(0)
+	assert.NoError(t, c.Validate(data.Monitor{URL: "https://www.google.com", Method: "GET", Config: []byte(`{"timeout_seconds": 5}`)}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "https://www.google.com", Method: "GET", Config: []byte(`{"timeout": 5}`)}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "https://www.google.com", Method: "GET", Config: []byte(`{"timeout_seconds": -1}`)}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "tcp://localhost:5432", Method: "GET"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "https://www.google.com", Method: "GET", Headers: "[]"}))
+}
@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+const TypeTCP = "tcp"
+
+// TCPConfig is the config of the tcp monitors.
+type TCPConfig struct {
+	TimeoutConfig
+}
+
+// TCPChecker opens a TCP connection to the URL of the monitor, that is a host:port address like
+// db.internal:5432. The check succeeds when the connection is established within the timeout, and
+// it is closed right away without sending any data.
+type TCPChecker struct {
+	Timeout  time.Duration
+	Resolver *net.Resolver
+}
+
+func NewTCPChecker(timeout time.Duration) *TCPChecker {
+	return &TCPChecker{Timeout: timeout, Resolver: net.DefaultResolver}
+}
+
+func (c *TCPChecker) Validate(monitor data.Monitor) error {
+	v := validator.New()
+	var config TCPConfig
+	if err := decodeConfig(monitor.Config, &config); err != nil {
+		v.AddError("config", err.Error())
+	}
+	config.validateTimeout(v)
+	host, port, err := net.SplitHostPort(monitor.URL)
+	v.Check(err == nil && host != "" && validPort(port), "url", "must be a host:port address")
+	checkHTTPFieldsUnused(v, monitor, TypeTCP)
+	if !v.Valid() {
+		return &data.ValidationError{Fields: v.Errors}
+	}
+	return nil
+}
+
+// Check resolves the host and connects to its addresses in order until one of them accepts the
+// connection. The latency is the time spent connecting to the last address, without the DNS
+// resolution, and the ResolvedIP is that address.
+func (c *TCPChecker) Check(ctx context.Context, monitor data.Monitor) Result {
+	result := Result{MonitorID: monitor.MonitorID, CheckedAt: time.Now().UTC()}
+
+	var config TCPConfig
+	if err := decodeConfig(monitor.Config, &config); err != nil {
+		result.fail(ErrorClassInvalidMonitor, err)
+		return result
+	}
+	host, port, err := net.SplitHostPort(monitor.URL)
+	if err != nil || !validPort(port) {
+		result.fail(ErrorClassInvalidMonitor, fmt.Errorf("invalid address %q, must be host:port", monitor.URL))
+		return result
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.timeout(c.Timeout))
+	defer cancel()
+
+	addrs, err := c.Resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		result.fail(classifyError(err), err)
+		return result
+	}
+	var dialer net.Dialer
+	for _, addr := range addrs {
+		result.ResolvedIP = addr.IP.String()
+		start := time.Now()
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(result.ResolvedIP, port))
+		result.Latency = time.Since(start)
+		if err == nil {
+			conn.Close()
+			result.Success = true
+			return result
+		}
+	}
+	result.fail(classifyError(err), err)
+	return result
+}
+
+// validPort returns true if the port is a number between 1 and 65535.
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
@@ -0,0 +1,97 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTCPChecker_Check(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	//get an address that refuses connections
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		monitor    data.Monitor
+		success    bool
+		resolvedIP string
+		errorClass ErrorClass
+	}{
+		{
+			name:       "Test Check connection established",
+			monitor:    data.Monitor{URL: listener.Addr().String()},
+			success:    true,
+			resolvedIP: "127.0.0.1",
+		},
+		{
+			name:       "Test Check resolves the host",
+			monitor:    data.Monitor{URL: net.JoinHostPort("localhost", port)},
+			success:    true,
+			resolvedIP: "127.0.0.1",
+		},
+		{
+			name:       "Test Check connection refused",
+			monitor:    data.Monitor{URL: closedAddr},
+			resolvedIP: "127.0.0.1",
+			errorClass: ErrorClassConnectionRefused,
+		},
+		{
+			name:       "Test Check invalid address",
+			monitor:    data.Monitor{URL: "localhost"},
+			errorClass: ErrorClassInvalidMonitor,
+		},
+		{
+			name:       "Test Check unknown host",
+			monitor:    data.Monitor{URL: "jojo.invalid:5432"},
+			errorClass: ErrorClassDNS,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewTCPChecker(time.Second)
+			result := c.Check(context.Background(), tt.monitor)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			assert.Equal(t, tt.errorClass, result.ErrorClass)
+			if tt.resolvedIP != "" {
+				assert.Equal(t, tt.resolvedIP, result.ResolvedIP)
+			}
+		})
+	}
+}
+
+func TestTCPChecker_Validate(t *testing.T) {
+	c := NewTCPChecker(0)
+	assert.NoError(t, c.Validate(data.Monitor{URL: "db.internal:5432", Config: []byte(`{"timeout_seconds": 5}`)}))
+	assert.NoError(t, c.Validate(data.Monitor{URL: "[::1]:6379"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "db.internal"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "db.internal:0"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "tcp://db.internal:5432"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "db.internal:5432", Method: "GET"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "db.internal:5432", Config: []byte(`{"port": 5432}`)}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "db.internal:5432", Config: []byte(`{"timeout_seconds": -1}`)}))
+}
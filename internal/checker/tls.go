@@ -0,0 +1,160 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+const TypeTLS = "tls"
+
+const (
+	DefaultExpiryDays = 14
+	MaxExpiryDays     = 3650
+	defaultTLSPort    = "443"
+)
+
+// TLSConfig is the config of the tls monitors.
+type TLSConfig struct {
+	TimeoutConfig
+	// ExpiryDays fails the check when the leaf certificate expires within this number of days.
+	ExpiryDays int `json:"expiry_days"`
+	// ServerName is sent in the SNI and verified against the certificate, instead of the host.
+	ServerName string `json:"server_name,omitempty"`
+	// CAPEM is a PEM encoded list of CA certificates used to verify the chain instead of the
+	// system pool, for services that use a private CA.
+	CAPEM string `json:"ca_pem,omitempty"`
+}
+
+// TLSChecker connects to the URL of the monitor, that is a host or host:port address (the port
+// defaults to 443), and inspects the certificate chain served in the handshake. The check fails
+// when the chain does not verify, the hostname does not match or the leaf certificate expires
+// within ExpiryDays.
+type TLSChecker struct {
+	Timeout time.Duration
+	// Roots is the pool used when the monitor has no CA, nil means the system pool.
+	Roots *x509.CertPool
+}
+
+func NewTLSChecker(timeout time.Duration) *TLSChecker {
+	return &TLSChecker{Timeout: timeout}
+}
+
+// tlsConfig decodes the config of the monitor, filling the defaults.
+func tlsConfig(monitor data.Monitor) (TLSConfig, error) {
+	config := TLSConfig{ExpiryDays: DefaultExpiryDays}
+	err := decodeConfig(monitor.Config, &config)
+	return config, err
+}
+
+// tlsAddress returns the host and port of the URL of a tls monitor.
+func tlsAddress(address string) (string, string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port, err = address, defaultTLSPort, nil
+	}
+	if host == "" || !validPort(port) {
+		return "", "", fmt.Errorf("invalid address %q, must be host or host:port", address)
+	}
+	return host, port, nil
+}
+
+func (c *TLSChecker) Validate(monitor data.Monitor) error {
+	v := validator.New()
+	config, err := tlsConfig(monitor)
+	if err != nil {
+		v.AddError("config", err.Error())
+	}
+	config.validateTimeout(v)
+	v.Check(config.ExpiryDays >= 0 && config.ExpiryDays <= MaxExpiryDays, "config", fmt.Sprintf("expiry_days must be between 0 and %d", MaxExpiryDays))
+	if config.CAPEM != "" {
+		v.Check(x509.NewCertPool().AppendCertsFromPEM([]byte(config.CAPEM)), "config", "ca_pem must contain at least one PEM encoded certificate")
+	}
+	_, _, err = tlsAddress(monitor.URL)
+	v.Check(err == nil, "url", "must be a host or host:port address")
+	checkHTTPFieldsUnused(v, monitor, TypeTLS)
+	if !v.Valid() {
+		return &data.ValidationError{Fields: v.Errors}
+	}
+	return nil
+}
+
+func (c *TLSChecker) Check(ctx context.Context, monitor data.Monitor) Result {
+	result := Result{MonitorID: monitor.MonitorID, CheckedAt: time.Now().UTC()}
+
+	config, err := tlsConfig(monitor)
+	if err != nil {
+		result.fail(ErrorClassInvalidMonitor, err)
+		return result
+	}
+	host, port, err := tlsAddress(monitor.URL)
+	if err != nil {
+		result.fail(ErrorClassInvalidMonitor, err)
+		return result
+	}
+	roots := c.Roots
+	if config.CAPEM != "" {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(config.CAPEM)) {
+			result.fail(ErrorClassInvalidMonitor, errors.New("ca_pem does not contain any certificate"))
+			return result
+		}
+	}
+	serverName := host
+	if config.ServerName != "" {
+		serverName = config.ServerName
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.timeout(c.Timeout))
+	defer cancel()
+
+	//the chain is verified after the handshake, so the certificates are recorded even when it is
+	//not valid
+	dialer := tls.Dialer{Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true}}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.fail(classifyError(err), err)
+		return result
+	}
+	defer conn.Close()
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		result.ResolvedIP = addr.IP.String()
+	}
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		result.fail(ErrorClassTLS, errors.New("the server did not send any certificate"))
+		return result
+	}
+	leaf := certs[0]
+	result.recordCertificate(leaf)
+
+	opts := x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   result.CheckedAt,
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		result.fail(ErrorClassTLS, err)
+		return result
+	}
+	if leaf.NotAfter.Before(result.CheckedAt.AddDate(0, 0, config.ExpiryDays)) {
+		days := int(leaf.NotAfter.Sub(result.CheckedAt).Hours() / 24)
+		result.fail(ErrorClassCertExpiring, fmt.Errorf("certificate expires in %d days, on %s", days, leaf.NotAfter.UTC().Format(time.RFC3339)))
+		return result
+	}
+	result.Success = true
+	return result
+}
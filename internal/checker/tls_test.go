@@ -0,0 +1,151 @@
+package checker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTLSServer serves a self-signed certificate for localhost that expires after the given
+// duration, returning its address and the certificate encoded as PEM.
+func newTLSServer(t *testing.T, expiresIn time.Duration) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "simplemon test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(expiresIn),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String(), string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func tlsMonitor(url string, config TLSConfig) data.Monitor {
+	raw, _ := json.Marshal(config)
+	return data.Monitor{MonitorType: TypeTLS, URL: url, Config: raw}
+}
+
+func TestTLSChecker_Check(t *testing.T) {
+	addr, caPEM := newTLSServer(t, 365*24*time.Hour)
+	expiringAddr, expiringCAPEM := newTLSServer(t, 5*24*time.Hour)
+
+	tests := []struct {
+		name       string
+		monitor    data.Monitor
+		success    bool
+		errorClass ErrorClass
+	}{
+		{
+			name:    "Test Check valid chain with custom CA",
+			monitor: tlsMonitor(addr, TLSConfig{ExpiryDays: 14, CAPEM: caPEM}),
+			success: true,
+		},
+		{
+			name:    "Test Check server name",
+			monitor: tlsMonitor(addr, TLSConfig{ExpiryDays: 14, CAPEM: caPEM, ServerName: "localhost"}),
+			success: true,
+		},
+		{
+			name:       "Test Check chain not trusted by the system pool",
+			monitor:    tlsMonitor(addr, TLSConfig{ExpiryDays: 14}),
+			errorClass: ErrorClassTLS,
+		},
+		{
+			name:       "Test Check hostname mismatch",
+			monitor:    tlsMonitor(addr, TLSConfig{ExpiryDays: 14, CAPEM: caPEM, ServerName: "www.google.com"}),
+			errorClass: ErrorClassTLS,
+		},
+		{
+			name:       "Test Check certificate expiring",
+			monitor:    tlsMonitor(expiringAddr, TLSConfig{ExpiryDays: 14, CAPEM: expiringCAPEM}),
+			errorClass: ErrorClassCertExpiring,
+		},
+		{
+			name:    "Test Check certificate expiring without expiry days",
+			monitor: tlsMonitor(expiringAddr, TLSConfig{CAPEM: expiringCAPEM}),
+			success: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewTLSChecker(time.Second)
+			result := c.Check(context.Background(), tt.monitor)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			assert.Equal(t, tt.errorClass, result.ErrorClass)
+			assert.Equal(t, "CN=simplemon test", result.CertIssuer)
+			assert.False(t, result.CertExpiresAt.IsZero())
+			assert.Equal(t, "127.0.0.1", result.ResolvedIP)
+		})
+	}
+}
+
+func TestTLSChecker_CheckConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	result := NewTLSChecker(time.Second).Check(context.Background(), data.Monitor{URL: closedAddr})
+	assert.False(t, result.Success)
+	assert.Equal(t, ErrorClassConnectionRefused, result.ErrorClass)
+	assert.True(t, result.CertExpiresAt.IsZero())
+}
+
+func TestTLSChecker_Validate(t *testing.T) {
+	_, caPEM := newTLSServer(t, time.Hour)
+	c := NewTLSChecker(0)
+	assert.NoError(t, c.Validate(data.Monitor{URL: "www.google.com"}))
+	assert.NoError(t, c.Validate(tlsMonitor("smtp.google.com:465", TLSConfig{ExpiryDays: 30, CAPEM: caPEM})))
+	assert.Error(t, c.Validate(data.Monitor{URL: "https://www.google.com"}))
+	assert.Error(t, c.Validate(data.Monitor{URL: "www.google.com", Method: "GET"}))
+	assert.Error(t, c.Validate(tlsMonitor("www.google.com", TLSConfig{ExpiryDays: -1})))
+	assert.Error(t, c.Validate(tlsMonitor("www.google.com", TLSConfig{CAPEM: "jojo"})))
+	assert.Error(t, c.Validate(tlsMonitor("www.google.com", TLSConfig{TimeoutConfig: TimeoutConfig{TimeoutSeconds: -1}})))
+	assert.Error(t, c.Validate(data.Monitor{URL: "www.google.com", Config: []byte(`{"days": 30}`)}))
+}
@@ -0,0 +1,177 @@
+// This file contains the APIKey struct, that authenticates the clients of the API as the user with
+// its email. Only the SHA-256 hash of the key is stored, the key itself is returned once, when it
+// is created.
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// APIKeyPrefix starts all the keys, so they are easy to recognize, e.g. by secret scanners.
+	APIKeyPrefix        = "smk_"
+	MaxAPIKeyNameLength = 100
+	// apiKeyVisibleLength is the length of the start of the key stored in clear, so the user can
+	// tell the keys apart.
+	apiKeyVisibleLength = len(APIKeyPrefix) + 8
+)
+
+type APIKey struct {
+	APIKeyID   int64      `json:"api_key_id"`
+	UserEmail  string     `json:"user_email"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`        // Start of the key, like smk_AbCd1234
+	Plaintext  string     `json:"key,omitempty"` // Only known when the key is created
+	Hash       []byte     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type APIKeyModel struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyModel(db *sql.DB) *APIKeyModel {
+	return &APIKeyModel{DB: db}
+}
+
+// APIKeyInterface stores the keys. The owner arguments are the email of the user the keys belong
+// to, an empty owner matches the keys of all the users.
+type APIKeyInterface interface {
+	Insert(ctx context.Context, key APIKey, log zerolog.Logger) (*APIKey, error)
+	GetByHash(ctx context.Context, hash []byte, log zerolog.Logger) (*APIKey, error)
+	GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]APIKey, error)
+	Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error
+}
+
+var ErrAPIKeyNotFound = &NotFoundError{Resource: "api key"}
+
+// GenerateAPIKey returns a new random key of the user, with its hash.
+func GenerateAPIKey(userEmail, name string) (*APIKey, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	plaintext := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+	return &APIKey{
+		UserEmail: userEmail,
+		Name:      name,
+		Prefix:    plaintext[:apiKeyVisibleLength],
+		Plaintext: plaintext,
+		Hash:      HashAPIKey(plaintext),
+	}, nil
+}
+
+// HashAPIKey returns the hash stored for the key. The keys are random, so a fast hash is enough.
+func HashAPIKey(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+func ValidateAPIKey(v *validator.Validator, key APIKey) {
+	v.Check(key.UserEmail != "", "user_email", "is required")
+	v.Check(validator.IsEmail(key.UserEmail), "user_email", "must be a valid email address")
+	v.Check(key.Name != "", "name", "is required")
+	v.Check(len(key.Name) <= MaxAPIKeyNameLength, "name", fmt.Sprintf("must not be longer than %d bytes", MaxAPIKeyNameLength))
+}
+
+func (m *APIKeyModel) Insert(ctx context.Context, key APIKey, log zerolog.Logger) (*APIKey, error) {
+	log.Info().Msg("Creating api key")
+	var psqlErr *pq.Error
+	err := m.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_email, name, prefix, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING api_key_id, created_at`,
+		key.UserEmail, key.Name, key.Prefix, key.Hash).Scan(&key.APIKeyID, &key.CreatedAt)
+	if err != nil {
+		log.Err(err).Msg("Error creating api key")
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23505" { // 23505 is unique_violation
+			return nil, &ConflictError{Resource: "api key", Reason: "an api key with the same hash already exists"}
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash returns the key with the hash, recording that it was used.
+func (m *APIKeyModel) GetByHash(ctx context.Context, hash []byte, log zerolog.Logger) (*APIKey, error) {
+	var key APIKey
+	err := m.DB.QueryRowContext(ctx, `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE key_hash = $1
+		RETURNING api_key_id, user_email, name, prefix, created_at, last_used_at`,
+		hash).Scan(&key.APIKeyID, &key.UserEmail, &key.Name, &key.Prefix, &key.CreatedAt, &key.LastUsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		log.Err(err).Msg("Error getting api key by hash")
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (m *APIKeyModel) GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]APIKey, error) {
+	log.Info().Msg("Getting api keys")
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT api_key_id, user_email, name, prefix, created_at, last_used_at
+		FROM api_keys
+		WHERE ($1 = '' OR user_email = $1)
+		ORDER BY api_key_id`,
+		owner)
+	if err != nil {
+		log.Err(err).Msg("Error getting api keys")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		err := rows.Scan(&key.APIKeyID, &key.UserEmail, &key.Name, &key.Prefix, &key.CreatedAt, &key.LastUsedAt)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Delete revokes the key, the requests using it are rejected from then on.
+func (m *APIKeyModel) Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error {
+	log.Info().Msg("Deleting api key")
+	result, err := m.DB.ExecContext(ctx, `
+		DELETE FROM api_keys
+		WHERE api_key_id = $1 AND ($2 = '' OR user_email = $2)`,
+		id, owner)
+	if err != nil {
+		log.Err(err).Msg("Error deleting api key")
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the deleted rows")
+		return err
+	}
+	if rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
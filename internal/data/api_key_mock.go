@@ -0,0 +1,36 @@
+package data
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeyModelMock struct {
+	mock.Mock
+}
+
+func NewAPIKeyModelMock() *APIKeyModelMock {
+	return &APIKeyModelMock{}
+}
+
+func (m *APIKeyModelMock) Insert(ctx context.Context, key APIKey, log zerolog.Logger) (*APIKey, error) {
+	args := m.Called(ctx, key, log)
+	return args.Get(0).(*APIKey), args.Error(1)
+}
+
+func (m *APIKeyModelMock) GetByHash(ctx context.Context, hash []byte, log zerolog.Logger) (*APIKey, error) {
+	args := m.Called(ctx, hash, log)
+	return args.Get(0).(*APIKey), args.Error(1)
+}
+
+func (m *APIKeyModelMock) GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]APIKey, error) {
+	args := m.Called(ctx, owner, log)
+	return args.Get(0).([]APIKey), args.Error(1)
+}
+
+func (m *APIKeyModelMock) Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error {
+	args := m.Called(ctx, id, owner, log)
+	return args.Error(0)
+}
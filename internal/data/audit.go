@@ -0,0 +1,162 @@
+// This file contains the AuditEvent struct, that records a change made through the API, and the
+// functions to append the events to the audit log and query it. The events are never changed or
+// deleted, the audit_events table rejects the updates and the deletes.
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Actions recorded in the audit log, named <target type>.<verb>.
+const (
+	AuditMonitorCreate    = "monitor.create"
+	AuditMonitorUpdate    = "monitor.update"
+	AuditMonitorDelete    = "monitor.delete"
+	AuditMonitorRestore   = "monitor.restore"
+	AuditMonitorLink      = "monitor.link_channel"
+	AuditMonitorUnlink    = "monitor.unlink_channel"
+	AuditChannelCreate    = "channel.create"
+	AuditChannelUpdate    = "channel.update"
+	AuditChannelDelete    = "channel.delete"
+	AuditAPIKeyCreate     = "api_key.create"
+	AuditAPIKeyDelete     = "api_key.delete"
+	AuditOrgCreate        = "org.create"
+	AuditOrgDelete        = "org.delete"
+	AuditOrgSetMember     = "org.set_member"
+	AuditOrgRemoveMember  = "org.remove_member"
+	AuditTeamCreate       = "team.create"
+	AuditTeamDelete       = "team.delete"
+	AuditTeamAddMember    = "team.add_member"
+	AuditTeamRemoveMember = "team.remove_member"
+)
+
+// AuditActorAdmin is the actor of the changes made with the admin key.
+const AuditActorAdmin = "admin"
+
+const (
+	DefaultAuditEventLimit = 100
+	MaxAuditEventLimit     = 1000
+)
+
+var AuditActions = []string{
+	AuditMonitorCreate, AuditMonitorUpdate, AuditMonitorDelete, AuditMonitorRestore, AuditMonitorLink, AuditMonitorUnlink,
+	AuditChannelCreate, AuditChannelUpdate, AuditChannelDelete,
+	AuditAPIKeyCreate, AuditAPIKeyDelete,
+	AuditOrgCreate, AuditOrgDelete, AuditOrgSetMember, AuditOrgRemoveMember,
+	AuditTeamCreate, AuditTeamDelete, AuditTeamAddMember, AuditTeamRemoveMember,
+}
+
+type AuditEvent struct {
+	AuditEventID int64           `json:"audit_event_id"`
+	Actor        string          `json:"actor"`                // Email of the user of the API key, or admin
+	APIKeyID     int64           `json:"api_key_id,omitempty"` // Key used by the actor, empty for the admin
+	Action       string          `json:"action"`
+	TargetType   string          `json:"target_type"`
+	TargetID     string          `json:"target_id"`
+	OrgID        int64           `json:"org_id,omitempty"` // Organization of the target, empty when it has none
+	Before       json.RawMessage `json:"before"`           // Target before the change, null when it was created
+	After        json.RawMessage `json:"after"`            // Target after the change, null when it was deleted
+	RequestID    string          `json:"request_id"`
+	SourceIP     string          `json:"source_ip"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// AuditFilter limits the events returned by GetAll. Zero values are ignored.
+type AuditFilter struct {
+	Owner  string // Email of the user making the request, see AuditInterface
+	Actor  string
+	Action string
+	Since  time.Time // Only the events created at or after this time
+	Until  time.Time // Only the events created before this time
+	Limit  int
+}
+
+type AuditModel struct {
+	DB *sql.DB
+}
+
+func NewAuditModel(db *sql.DB) *AuditModel {
+	return &AuditModel{DB: db}
+}
+
+// AuditInterface appends the events to the audit log and queries them. The users see their own
+// events and the events of the organizations they own, an empty owner matches all the events.
+type AuditInterface interface {
+	Insert(ctx context.Context, event AuditEvent, log zerolog.Logger) error
+	GetAll(ctx context.Context, filter AuditFilter, log zerolog.Logger) ([]AuditEvent, error)
+}
+
+func (m *AuditModel) Insert(ctx context.Context, event AuditEvent, log zerolog.Logger) error {
+	_, err := m.DB.ExecContext(ctx, `
+		INSERT INTO audit_events (actor, api_key_id, action, target_type, target_id, org_id, before, after, request_id, source_ip)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, NULLIF($6, 0), $7, $8, $9, $10)`,
+		event.Actor, event.APIKeyID, event.Action, event.TargetType, event.TargetID, event.OrgID,
+		nullableJSON(event.Before), nullableJSON(event.After), event.RequestID, event.SourceIP)
+	if err != nil {
+		log.Err(err).Msg("Error inserting audit event")
+		return err
+	}
+	return nil
+}
+
+// GetAll returns the most recent events first.
+func (m *AuditModel) GetAll(ctx context.Context, filter AuditFilter, log zerolog.Logger) ([]AuditEvent, error) {
+	log.Info().Msg("Getting audit events")
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditEventLimit
+	}
+	var since, until sql.NullTime
+	if !filter.Since.IsZero() {
+		since = sql.NullTime{Time: filter.Since, Valid: true}
+	}
+	if !filter.Until.IsZero() {
+		until = sql.NullTime{Time: filter.Until, Valid: true}
+	}
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT audit_event_id, actor, COALESCE(api_key_id, 0), action, target_type, target_id, COALESCE(org_id, 0),
+			COALESCE(before, 'null'), COALESCE(after, 'null'), request_id, source_ip, created_at
+		FROM audit_events
+		WHERE ($1 = '' OR actor = $1)
+		AND ($2 = '' OR action = $2)
+		AND ($3::timestamptz IS NULL OR created_at >= $3)
+		AND ($4::timestamptz IS NULL OR created_at < $4)
+		AND ($5 = '' OR actor = $5 OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $5 AND role = 'owner'))
+		ORDER BY created_at DESC, audit_event_id DESC
+		LIMIT $6`,
+		filter.Actor, filter.Action, since, until, filter.Owner, filter.Limit)
+	if err != nil {
+		log.Err(err).Msg("Error getting audit events")
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []AuditEvent{}
+	for rows.Next() {
+		var event AuditEvent
+		err := rows.Scan(&event.AuditEventID, &event.Actor, &event.APIKeyID, &event.Action, &event.TargetType, &event.TargetID, &event.OrgID,
+			&event.Before, &event.After, &event.RequestID, &event.SourceIP, &event.CreatedAt)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return events, nil
+}
+
+// nullableJSON returns the snapshot as text for the jsonb columns, NULL when there is none.
+func nullableJSON(js json.RawMessage) sql.NullString {
+	if len(js) == 0 || string(js) == "null" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(js), Valid: true}
+}
@@ -0,0 +1,26 @@
+package data
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditModelMock struct {
+	mock.Mock
+}
+
+func NewAuditModelMock() *AuditModelMock {
+	return &AuditModelMock{}
+}
+
+func (m *AuditModelMock) Insert(ctx context.Context, event AuditEvent, log zerolog.Logger) error {
+	args := m.Called(ctx, event, log)
+	return args.Error(0)
+}
+
+func (m *AuditModelMock) GetAll(ctx context.Context, filter AuditFilter, log zerolog.Logger) ([]AuditEvent, error) {
+	args := m.Called(ctx, filter, log)
+	return args.Get(0).([]AuditEvent), args.Error(1)
+}
@@ -0,0 +1,68 @@
+// This file contains the Certificate struct, that is the last TLS certificate seen by the checks of
+// a monitor, and the query used to find the certificates that are about to expire.
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type Certificate struct {
+	MonitorID   int64     `json:"monitor_id"`
+	MonitorType string    `json:"type"`
+	URL         string    `json:"url"`
+	Issuer      string    `json:"issuer"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CheckedAt   time.Time `json:"checked_at"` // When the certificate was seen for the last time
+}
+
+const (
+	DefaultCertificateLimit = 100
+	MaxCertificateLimit     = 1000
+)
+
+// GetExpiringCertificates returns the last certificate seen by each monitor of the owner, see
+// MonitorInterface, that expires before the given time. The ones that expire first are returned
+// first.
+func (m *CheckResultModel) GetExpiringCertificates(ctx context.Context, before time.Time, limit int, owner string, log zerolog.Logger) ([]Certificate, error) {
+	log.Info().Msg("Getting expiring certificates")
+	if limit <= 0 {
+		limit = DefaultCertificateLimit
+	}
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT c.monitor_id, m.type, m.url, c.cert_issuer, c.cert_expires_at, c.checked_at
+		FROM (
+			SELECT DISTINCT ON (monitor_id) monitor_id, cert_issuer, cert_expires_at, checked_at
+			FROM check_results
+			WHERE cert_expires_at IS NOT NULL
+			ORDER BY monitor_id, checked_at DESC
+		) c
+		JOIN monitors m ON m.monitor_id = c.monitor_id
+		WHERE c.cert_expires_at < $1 AND m.deleted_at IS NULL AND ($3 = '' OR m.org_id IN (SELECT org_id FROM org_roles WHERE user_email = $3))
+		ORDER BY c.cert_expires_at, c.monitor_id
+		LIMIT $2`,
+		before, limit, owner)
+	if err != nil {
+		log.Err(err).Msg("Error getting expiring certificates")
+		return nil, err
+	}
+	defer rows.Close()
+
+	certificates := []Certificate{}
+	for rows.Next() {
+		var certificate Certificate
+		err := rows.Scan(&certificate.MonitorID, &certificate.MonitorType, &certificate.URL, &certificate.Issuer, &certificate.ExpiresAt, &certificate.CheckedAt)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return certificates, nil
+}
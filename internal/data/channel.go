@@ -0,0 +1,251 @@
+// This file contains the Channel struct, that is a destination of the notifications, like a list of
+// email addresses or a webhook URL, and the functions to store the channels and link them to the
+// monitors. A monitor can alert many channels and a channel can be reused by many monitors.
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+// MaxChannelNameLength is the max length of the name of a channel.
+const MaxChannelNameLength = 100
+
+type Channel struct {
+	ChannelID int64           `json:"channel_id"`
+	OrgID     int64           `json:"org_id"`     // Organization that owns the channel, 0 for the channels of no organization
+	UserEmail string          `json:"user_email"` // Contact of the channel, a member of its organization
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind"`
+	Config    json.RawMessage `json:"config,omitempty"` // Settings specific to the kind, like the URL of a webhook
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type ChannelModel struct {
+	DB *sql.DB
+}
+
+func NewChannelModel(db *sql.DB) *ChannelModel {
+	return &ChannelModel{DB: db}
+}
+
+// ChannelInterface stores the channels and their links to the monitors. The owner arguments are the
+// email of the user making the request, like in MonitorInterface: only the channels of the
+// organizations the user is a member of are found, and an empty owner matches all the channels.
+type ChannelInterface interface {
+	Insert(ctx context.Context, channel Channel, log zerolog.Logger) (*Channel, error)
+	GetByID(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Channel, error)
+	GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]Channel, error)
+	Update(ctx context.Context, channel Channel, owner string, log zerolog.Logger) (*Channel, error)
+	Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error
+	GetByMonitor(ctx context.Context, monitorID int64, log zerolog.Logger) ([]Channel, error)
+	Link(ctx context.Context, monitorID, channelID int64, log zerolog.Logger) error
+	Unlink(ctx context.Context, monitorID, channelID int64, log zerolog.Logger) error
+}
+
+var (
+	ErrChannelNotFound     = &NotFoundError{Resource: "channel"}
+	ErrChannelLinkNotFound = &NotFoundError{Resource: "channel of the monitor"}
+	ErrChannelOtherOrg     = &ConflictError{Resource: "channel", Reason: "the channel belongs to another organization than the monitor"}
+)
+
+// ValidateChannel verifies the fields shared by all the channel kinds, adding an error to the
+// validator for each invalid field. The config of each kind is verified by the notify package.
+func ValidateChannel(v *validator.Validator, channel Channel) {
+	v.Check(channel.Name != "", "name", "is required")
+	v.Check(len(channel.Name) <= MaxChannelNameLength, "name", fmt.Sprintf("must not be longer than %d bytes", MaxChannelNameLength))
+	v.Check(channel.Kind != "", "kind", "is required")
+	v.Check(channel.OrgID != 0, "org_id", "is required")
+	v.Check(channel.UserEmail != "", "user_email", "is required")
+	if len(channel.Config) > 0 {
+		var config map[string]interface{}
+		v.Check(json.Unmarshal(channel.Config, &config) == nil, "config", "must be a JSON object")
+	}
+}
+
+// configJSON returns the config as text, so it can be stored in the jsonb column.
+func (c Channel) configJSON() string {
+	if len(c.Config) == 0 {
+		return "{}"
+	}
+	return string(c.Config)
+}
+
+func (m *ChannelModel) Insert(ctx context.Context, channel Channel, log zerolog.Logger) (*Channel, error) {
+	log.Info().Msg("Creating channel")
+	var psqlErr *pq.Error
+	err := m.DB.QueryRowContext(ctx, `
+		INSERT INTO channels (org_id, user_email, name, kind, config)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING channel_id, created_at, updated_at`,
+		channel.OrgID, channel.UserEmail, channel.Name, channel.Kind, channel.configJSON()).Scan(&channel.ChannelID, &channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		log.Err(err).Msg("Error creating channel")
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23503" { // 23503 is foreign_key_violation
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (m *ChannelModel) GetByID(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Channel, error) {
+	log.Info().Msg("Getting channel by id")
+	var channel Channel
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT channel_id, COALESCE(org_id, 0), user_email, name, kind, config, created_at, updated_at
+		FROM channels
+		WHERE channel_id = $1 AND ($2 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $2))`,
+		id, owner).Scan(&channel.ChannelID, &channel.OrgID, &channel.UserEmail, &channel.Name, &channel.Kind, &channel.Config, &channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChannelNotFound
+		}
+		log.Err(err).Msg("Error getting channel by id")
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (m *ChannelModel) GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]Channel, error) {
+	log.Info().Msg("Getting all channels")
+	return m.query(ctx, log, `
+		SELECT channel_id, COALESCE(org_id, 0), user_email, name, kind, config, created_at, updated_at
+		FROM channels
+		WHERE $1 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $1)
+		ORDER BY channel_id`,
+		owner)
+}
+
+// Update replaces the organization, user, name, kind and config of the channel identified by
+// channel.ChannelID.
+func (m *ChannelModel) Update(ctx context.Context, channel Channel, owner string, log zerolog.Logger) (*Channel, error) {
+	log.Info().Msg("Updating channel")
+	var psqlErr *pq.Error
+	err := m.DB.QueryRowContext(ctx, `
+		UPDATE channels
+		SET org_id = $2, user_email = $3, name = $4, kind = $5, config = $6, updated_at = NOW()
+		WHERE channel_id = $1 AND ($7 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $7))
+		RETURNING created_at, updated_at`,
+		channel.ChannelID, channel.OrgID, channel.UserEmail, channel.Name, channel.Kind, channel.configJSON(), owner).Scan(&channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChannelNotFound
+		}
+		log.Err(err).Msg("Error updating channel")
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23503" { // 23503 is foreign_key_violation
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// Delete removes the channel, and its links to the monitors by cascade.
+func (m *ChannelModel) Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error {
+	log.Info().Msg("Deleting channel")
+	result, err := m.DB.ExecContext(ctx, `
+		DELETE FROM channels
+		WHERE channel_id = $1 AND ($2 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $2))`,
+		id, owner)
+	if err != nil {
+		log.Err(err).Msg("Error deleting channel")
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the deleted rows")
+		return err
+	}
+	if rows == 0 {
+		return ErrChannelNotFound
+	}
+	return nil
+}
+
+// GetByMonitor returns the channels alerted by the monitor.
+func (m *ChannelModel) GetByMonitor(ctx context.Context, monitorID int64, log zerolog.Logger) ([]Channel, error) {
+	log.Debug().Msg("Getting channels by monitor")
+	return m.query(ctx, log, `
+		SELECT c.channel_id, COALESCE(c.org_id, 0), c.user_email, c.name, c.kind, c.config, c.created_at, c.updated_at
+		FROM channels c
+		JOIN monitor_channels mc ON mc.channel_id = c.channel_id
+		WHERE mc.monitor_id = $1
+		ORDER BY c.channel_id`,
+		monitorID)
+}
+
+// Link makes the monitor alert the channel. Linking them again does nothing.
+func (m *ChannelModel) Link(ctx context.Context, monitorID, channelID int64, log zerolog.Logger) error {
+	log.Info().Msg("Linking channel to monitor")
+	var psqlErr *pq.Error
+	_, err := m.DB.ExecContext(ctx, `
+		INSERT INTO monitor_channels (monitor_id, channel_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		monitorID, channelID)
+	if err != nil {
+		//the channel can be deleted after the handler verified that it exists
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23503" { // 23503 is foreign_key_violation
+			return ErrChannelNotFound
+		}
+		log.Err(err).Msg("Error linking channel to monitor")
+		return err
+	}
+	return nil
+}
+
+func (m *ChannelModel) Unlink(ctx context.Context, monitorID, channelID int64, log zerolog.Logger) error {
+	log.Info().Msg("Unlinking channel from monitor")
+	result, err := m.DB.ExecContext(ctx, `
+		DELETE FROM monitor_channels
+		WHERE monitor_id = $1 AND channel_id = $2`,
+		monitorID, channelID)
+	if err != nil {
+		log.Err(err).Msg("Error unlinking channel from monitor")
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the deleted rows")
+		return err
+	}
+	if rows == 0 {
+		return ErrChannelLinkNotFound
+	}
+	return nil
+}
+
+func (m *ChannelModel) query(ctx context.Context, log zerolog.Logger, query string, args ...interface{}) ([]Channel, error) {
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Err(err).Msg("Error getting channels")
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := []Channel{}
+	for rows.Next() {
+		var channel Channel
+		err := rows.Scan(&channel.ChannelID, &channel.OrgID, &channel.UserEmail, &channel.Name, &channel.Kind, &channel.Config, &channel.CreatedAt, &channel.UpdatedAt)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return channels, nil
+}
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type ChannelModelMock struct {
+	mock.Mock
+}
+
+func NewChannelModelMock() *ChannelModelMock {
+	return &ChannelModelMock{}
+}
+
+func (m *ChannelModelMock) Insert(ctx context.Context, channel Channel, log zerolog.Logger) (*Channel, error) {
+	args := m.Called(ctx, channel, log)
+	return args.Get(0).(*Channel), args.Error(1)
+}
+
+func (m *ChannelModelMock) GetByID(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Channel, error) {
+	args := m.Called(ctx, id, owner, log)
+	return args.Get(0).(*Channel), args.Error(1)
+}
+
+func (m *ChannelModelMock) GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]Channel, error) {
+	args := m.Called(ctx, owner, log)
+	return args.Get(0).([]Channel), args.Error(1)
+}
+
+func (m *ChannelModelMock) Update(ctx context.Context, channel Channel, owner string, log zerolog.Logger) (*Channel, error) {
+	args := m.Called(ctx, channel, owner, log)
+	return args.Get(0).(*Channel), args.Error(1)
+}
+
+func (m *ChannelModelMock) Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error {
+	args := m.Called(ctx, id, owner, log)
+	return args.Error(0)
+}
+
+func (m *ChannelModelMock) GetByMonitor(ctx context.Context, monitorID int64, log zerolog.Logger) ([]Channel, error) {
+	args := m.Called(ctx, monitorID, log)
+	return args.Get(0).([]Channel), args.Error(1)
+}
+
+func (m *ChannelModelMock) Link(ctx context.Context, monitorID, channelID int64, log zerolog.Logger) error {
+	args := m.Called(ctx, monitorID, channelID, log)
+	return args.Error(0)
+}
+
+func (m *ChannelModelMock) Unlink(ctx context.Context, monitorID, channelID int64, log zerolog.Logger) error {
+	args := m.Called(ctx, monitorID, channelID, log)
+	return args.Error(0)
+}
@@ -0,0 +1,114 @@
+// This file contains the CheckResult struct, that stores what happened every time a monitor was
+// checked, and the functions to store and query the results history.
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type CheckResult struct {
+	CheckResultID   int64      `json:"check_result_id"`
+	MonitorID       int64      `json:"monitor_id"`
+	CheckedAt       time.Time  `json:"checked_at"`
+	Success         bool       `json:"success"`
+	StatusCode      int        `json:"status_code"`
+	LatencyMs       int64      `json:"latency_ms"`
+	ResponseSize    int64      `json:"response_size"`
+	ResolvedIP      string     `json:"resolved_ip"`
+	ErrorClass      string     `json:"error_class"`
+	Error           string     `json:"error"`
+	CertExpiresAt   *time.Time `json:"cert_expires_at"` // Leaf certificate of the endpoint, for the types that use TLS
+	CertIssuer      string     `json:"cert_issuer"`
+	FailedAssertion string     `json:"failed_assertion"` // Assertion that failed, for the types that have assertions
+	ActualValue     string     `json:"actual_value"`     // Value the failed assertion got
+}
+
+// CheckResultFilter limits the results returned by GetByMonitor. Zero From/To are ignored.
+type CheckResultFilter struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+const (
+	DefaultCheckResultLimit = 100
+	MaxCheckResultLimit     = 1000
+)
+
+type CheckResultModel struct {
+	DB *sql.DB
+}
+
+func NewCheckResultModel(db *sql.DB) *CheckResultModel {
+	return &CheckResultModel{DB: db}
+}
+
+type CheckResultInterface interface {
+	Insert(ctx context.Context, result CheckResult, log zerolog.Logger) (*CheckResult, error)
+	GetByMonitor(ctx context.Context, monitorID int64, filter CheckResultFilter, log zerolog.Logger) ([]CheckResult, error)
+	GetExpiringCertificates(ctx context.Context, before time.Time, limit int, owner string, log zerolog.Logger) ([]Certificate, error)
+}
+
+func (m *CheckResultModel) Insert(ctx context.Context, result CheckResult, log zerolog.Logger) (*CheckResult, error) {
+	log.Debug().Msg("Inserting check result")
+	err := m.DB.QueryRowContext(ctx, `
+		INSERT INTO check_results (monitor_id, checked_at, success, status_code, latency_ms, response_size, resolved_ip, error_class, error, cert_expires_at, cert_issuer, failed_assertion, actual_value)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING check_result_id`,
+		result.MonitorID, result.CheckedAt, result.Success, result.StatusCode, result.LatencyMs, result.ResponseSize, result.ResolvedIP, result.ErrorClass, result.Error, result.CertExpiresAt, result.CertIssuer, result.FailedAssertion, result.ActualValue).Scan(&result.CheckResultID)
+	if err != nil {
+		log.Err(err).Msg("Error inserting check result")
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetByMonitor returns the most recent results of a monitor first. To get the next page, use the
+// CheckedAt of the last result as the To of the filter.
+func (m *CheckResultModel) GetByMonitor(ctx context.Context, monitorID int64, filter CheckResultFilter, log zerolog.Logger) ([]CheckResult, error) {
+	log.Info().Msg("Getting check results by monitor")
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultCheckResultLimit
+	}
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT check_result_id, monitor_id, checked_at, success, status_code, latency_ms, response_size, resolved_ip, error_class, error, cert_expires_at, cert_issuer, failed_assertion, actual_value
+		FROM check_results
+		WHERE monitor_id = $1
+		AND ($2::timestamptz IS NULL OR checked_at >= $2)
+		AND ($3::timestamptz IS NULL OR checked_at < $3)
+		ORDER BY checked_at DESC, check_result_id DESC
+		LIMIT $4`,
+		monitorID, from, to, filter.Limit)
+	if err != nil {
+		log.Err(err).Msg("Error getting check results")
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []CheckResult{}
+	for rows.Next() {
+		var result CheckResult
+		err := rows.Scan(&result.CheckResultID, &result.MonitorID, &result.CheckedAt, &result.Success, &result.StatusCode, &result.LatencyMs, &result.ResponseSize, &result.ResolvedIP, &result.ErrorClass, &result.Error, &result.CertExpiresAt, &result.CertIssuer, &result.FailedAssertion, &result.ActualValue)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return results, nil
+}
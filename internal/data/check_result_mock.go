@@ -0,0 +1,32 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type CheckResultModelMock struct {
+	mock.Mock
+}
+
+func NewCheckResultModelMock() *CheckResultModelMock {
+	return &CheckResultModelMock{}
+}
+
+func (m *CheckResultModelMock) Insert(ctx context.Context, result CheckResult, log zerolog.Logger) (*CheckResult, error) {
+	args := m.Called(ctx, result, log)
+	return args.Get(0).(*CheckResult), args.Error(1)
+}
+
+func (m *CheckResultModelMock) GetByMonitor(ctx context.Context, monitorID int64, filter CheckResultFilter, log zerolog.Logger) ([]CheckResult, error) {
+	args := m.Called(ctx, monitorID, filter, log)
+	return args.Get(0).([]CheckResult), args.Error(1)
+}
+
+func (m *CheckResultModelMock) GetExpiringCertificates(ctx context.Context, before time.Time, limit int, owner string, log zerolog.Logger) ([]Certificate, error) {
+	args := m.Called(ctx, before, limit, owner, log)
+	return args.Get(0).([]Certificate), args.Error(1)
+}
@@ -0,0 +1,60 @@
+// This file contains the typed errors returned by the models. The application maps them to
+// responses using errors.Is with ErrNotFound/ErrConflict and errors.As with *ValidationError,
+// instead of comparing the error messages.
+package data
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
+// NotFoundError is returned when a record does not exist. It matches ErrNotFound.
+type NotFoundError struct {
+	Resource string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ConflictError is returned when a record conflicts with an existing one. It matches ErrConflict.
+type ConflictError struct {
+	Resource string
+	Reason   string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Reason
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// ValidationError holds the problem of each invalid field, keyed by the JSON name of the field.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: map[string]string{field: message}}
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, message := range e.Fields {
+		fields = append(fields, field+": "+message)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, "; ")
+}
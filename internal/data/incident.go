@@ -0,0 +1,182 @@
+// This file contains the Incident struct, that groups the consecutive failures of a monitor, and
+// the functions to open, update, resolve and query the incidents.
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	IncidentStatusOpen     = "open"
+	IncidentStatusResolved = "resolved"
+)
+
+type Incident struct {
+	IncidentID    int64      `json:"incident_id"`
+	MonitorID     int64      `json:"monitor_id"`
+	Status        string     `json:"status"`
+	FailingSince  time.Time  `json:"failing_since"`
+	OpenedAt      time.Time  `json:"opened_at"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	FailureCount  int        `json:"failure_count"`
+	LastError     string     `json:"last_error"`
+}
+
+// IncidentFilter limits the incidents returned by GetAll. Zero values are ignored.
+type IncidentFilter struct {
+	Owner     string // Email of the user making the request, only the incidents of the monitors of its organizations are returned
+	MonitorID int64
+	Status    string
+	Limit     int
+}
+
+const (
+	DefaultIncidentLimit = 100
+	MaxIncidentLimit     = 1000
+)
+
+var ErrIncidentNotFound = &NotFoundError{Resource: "incident"}
+
+type IncidentModel struct {
+	DB *sql.DB
+}
+
+func NewIncidentModel(db *sql.DB) *IncidentModel {
+	return &IncidentModel{DB: db}
+}
+
+type IncidentInterface interface {
+	Open(ctx context.Context, incident Incident, log zerolog.Logger) (*Incident, error)
+	AttachFailure(ctx context.Context, id int64, failedAt time.Time, lastError string, log zerolog.Logger) error
+	Resolve(ctx context.Context, id int64, resolvedAt time.Time, log zerolog.Logger) (*Incident, error)
+	GetOpen(ctx context.Context, monitorID int64, log zerolog.Logger) (*Incident, error)
+	GetAll(ctx context.Context, filter IncidentFilter, log zerolog.Logger) ([]Incident, error)
+}
+
+const incidentColumns = `incident_id, monitor_id, failing_since, opened_at, resolved_at, last_failure_at, failure_count, last_error`
+
+func scanIncident(row interface{ Scan(dest ...any) error }) (*Incident, error) {
+	var incident Incident
+	err := row.Scan(&incident.IncidentID, &incident.MonitorID, &incident.FailingSince, &incident.OpenedAt, &incident.ResolvedAt, &incident.LastFailureAt, &incident.FailureCount, &incident.LastError)
+	if err != nil {
+		return nil, err
+	}
+	incident.Status = IncidentStatusOpen
+	if incident.ResolvedAt != nil {
+		incident.Status = IncidentStatusResolved
+	}
+	return &incident, nil
+}
+
+func (m *IncidentModel) Open(ctx context.Context, incident Incident, log zerolog.Logger) (*Incident, error) {
+	log.Info().Msg("Opening incident")
+	opened, err := scanIncident(m.DB.QueryRowContext(ctx, `
+		INSERT INTO incidents (monitor_id, failing_since, opened_at, last_failure_at, failure_count, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+incidentColumns,
+		incident.MonitorID, incident.FailingSince, incident.OpenedAt, incident.LastFailureAt, incident.FailureCount, incident.LastError))
+	if err != nil {
+		log.Err(err).Msg("Error opening incident")
+		return nil, err
+	}
+	return opened, nil
+}
+
+func (m *IncidentModel) AttachFailure(ctx context.Context, id int64, failedAt time.Time, lastError string, log zerolog.Logger) error {
+	log.Debug().Msg("Attaching failure to incident")
+	res, err := m.DB.ExecContext(ctx, `
+		UPDATE incidents
+		SET failure_count = failure_count + 1, last_failure_at = $2, last_error = $3
+		WHERE incident_id = $1 AND resolved_at IS NULL`,
+		id, failedAt, lastError)
+	if err != nil {
+		log.Err(err).Msg("Error attaching failure to incident")
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrIncidentNotFound
+	}
+	return nil
+}
+
+func (m *IncidentModel) Resolve(ctx context.Context, id int64, resolvedAt time.Time, log zerolog.Logger) (*Incident, error) {
+	log.Info().Msg("Resolving incident")
+	incident, err := scanIncident(m.DB.QueryRowContext(ctx, `
+		UPDATE incidents
+		SET resolved_at = $2
+		WHERE incident_id = $1 AND resolved_at IS NULL
+		RETURNING `+incidentColumns,
+		id, resolvedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIncidentNotFound
+		}
+		log.Err(err).Msg("Error resolving incident")
+		return nil, err
+	}
+	return incident, nil
+}
+
+// GetOpen returns the open incident of the monitor, or ErrIncidentNotFound if the monitor is healthy.
+func (m *IncidentModel) GetOpen(ctx context.Context, monitorID int64, log zerolog.Logger) (*Incident, error) {
+	incident, err := scanIncident(m.DB.QueryRowContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE monitor_id = $1 AND resolved_at IS NULL`,
+		monitorID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIncidentNotFound
+		}
+		log.Err(err).Msg("Error getting open incident")
+		return nil, err
+	}
+	return incident, nil
+}
+
+// GetAll returns the most recently opened incidents first.
+func (m *IncidentModel) GetAll(ctx context.Context, filter IncidentFilter, log zerolog.Logger) ([]Incident, error) {
+	log.Info().Msg("Getting incidents")
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultIncidentLimit
+	}
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE ($1 = 0 OR monitor_id = $1)
+		AND ($2 = '' OR ($2 = 'open' AND resolved_at IS NULL) OR ($2 = 'resolved' AND resolved_at IS NOT NULL))
+		AND ($4 = '' OR monitor_id IN (SELECT monitor_id FROM monitors WHERE org_id IN (SELECT org_id FROM org_roles WHERE user_email = $4)))
+		ORDER BY opened_at DESC, incident_id DESC
+		LIMIT $3`,
+		filter.MonitorID, filter.Status, filter.Limit, filter.Owner)
+	if err != nil {
+		log.Err(err).Msg("Error getting incidents")
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents := []Incident{}
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		incidents = append(incidents, *incident)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return incidents, nil
+}
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type IncidentModelMock struct {
+	mock.Mock
+}
+
+func NewIncidentModelMock() *IncidentModelMock {
+	return &IncidentModelMock{}
+}
+
+func (m *IncidentModelMock) Open(ctx context.Context, incident Incident, log zerolog.Logger) (*Incident, error) {
+	args := m.Called(ctx, incident, log)
+	return args.Get(0).(*Incident), args.Error(1)
+}
+
+func (m *IncidentModelMock) AttachFailure(ctx context.Context, id int64, failedAt time.Time, lastError string, log zerolog.Logger) error {
+	args := m.Called(ctx, id, failedAt, lastError, log)
+	return args.Error(0)
+}
+
+func (m *IncidentModelMock) Resolve(ctx context.Context, id int64, resolvedAt time.Time, log zerolog.Logger) (*Incident, error) {
+	args := m.Called(ctx, id, resolvedAt, log)
+	return args.Get(0).(*Incident), args.Error(1)
+}
+
+func (m *IncidentModelMock) GetOpen(ctx context.Context, monitorID int64, log zerolog.Logger) (*Incident, error) {
+	args := m.Called(ctx, monitorID, log)
+	return args.Get(0).(*Incident), args.Error(1)
+}
+
+func (m *IncidentModelMock) GetAll(ctx context.Context, filter IncidentFilter, log zerolog.Logger) ([]Incident, error) {
+	args := m.Called(ctx, filter, log)
+	return args.Get(0).([]Incident), args.Error(1)
+}
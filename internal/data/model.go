@@ -7,7 +7,14 @@ package data
 import "database/sql"
 
 type Models struct {
-	Monitor *MonitorModel
+	Monitor     *MonitorModel
+	CheckResult *CheckResultModel
+	Incident    *IncidentModel
+	Ping        *PingModel
+	Channel     *ChannelModel
+	APIKey      *APIKeyModel
+	Org         *OrgModel
+	Audit       *AuditModel
 }
 
 type ModelsInterface interface {
@@ -18,7 +25,14 @@ type ModelsInterface interface {
 func NewModels(db *sql.DB) Models {
 
 	return Models{
-		Monitor: NewMonitorModel(db),
+		Monitor:     NewMonitorModel(db),
+		CheckResult: NewCheckResultModel(db),
+		Incident:    NewIncidentModel(db),
+		Ping:        NewPingModel(db),
+		Channel:     NewChannelModel(db),
+		APIKey:      NewAPIKeyModel(db),
+		Org:         NewOrgModel(db),
+		Audit:       NewAuditModel(db),
 	}
 }
 
@@ -26,11 +40,25 @@ func NewModels(db *sql.DB) Models {
 // Mock version of the models
 
 type MockModels struct {
-	Monitor *MonitorModelMock
+	Monitor     *MonitorModelMock
+	CheckResult *CheckResultModelMock
+	Incident    *IncidentModelMock
+	Ping        *PingModelMock
+	Channel     *ChannelModelMock
+	APIKey      *APIKeyModelMock
+	Org         *OrgModelMock
+	Audit       *AuditModelMock
 }
 
 func NewMockModels() MockModels {
 	return MockModels{
-		Monitor: NewMonitorModelMock(),
+		Monitor:     NewMonitorModelMock(),
+		CheckResult: NewCheckResultModelMock(),
+		Incident:    NewIncidentModelMock(),
+		Ping:        NewPingModelMock(),
+		Channel:     NewChannelModelMock(),
+		APIKey:      NewAPIKeyModelMock(),
+		Org:         NewOrgModelMock(),
+		Audit:       NewAuditModelMock(),
 	}
 }
@@ -5,26 +5,45 @@ package data
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/The-Sailors/simplemon/internal/validator"
 	"github.com/lib/pq"
 	"github.com/rs/zerolog"
 )
 
+// Bounds of the monitor fields accepted by the API.
+const (
+	MinFrequencyMinutes  = 1
+	MaxFrequencyMinutes  = 24 * 60
+	MinThresholdMinutes  = 0
+	MaxThresholdMinutes  = 7 * 24 * 60
+	MaxURLLength         = 2048
+	MaxMethodLength      = 16
+	MaxBodyLength        = 64 * 1024
+	MaxKeyValuesLength   = 8 * 1024 // Max length of the encoded Headers and Parameters fields
+	MaxDescriptionLength = 1000
+)
+
 type Monitor struct {
-	MonitorID        int64     `json:"monitor_id" `
-	UserEmail        string    `json:"user_email"`
-	MonitorType      string    `json:"type"`
-	URL              string    `json:"url"`
-	Method           string    `json:"method"`
-	UpdatedAt        time.Time `json:"updated_at"`
-	Body             string    `json:"body"`
-	Headers          string    `json:"headers"`
-	Parameters       string    `json:"parameters"`
-	Description      string    `json:"description"`
-	FrequencyMinutes int       `json:"frequency_minutes"`
-	ThresholdMinutes int       `json:"threshold_minutes"`
+	MonitorID        int64           `json:"monitor_id" `
+	OrgID            int64           `json:"org_id"`     // Organization that owns the monitor
+	UserEmail        string          `json:"user_email"` // Contact of the monitor, that receives its notifications
+	MonitorType      string          `json:"type"`
+	URL              string          `json:"url"`
+	Method           string          `json:"method"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	Body             string          `json:"body"`
+	Headers          string          `json:"headers"`
+	Parameters       string          `json:"parameters"`
+	Description      string          `json:"description"`
+	FrequencyMinutes int             `json:"frequency_minutes"`
+	ThresholdMinutes int             `json:"threshold_minutes"`
+	Config           json.RawMessage `json:"config,omitempty"`     // Settings specific to the monitor type
+	DeletedAt        *time.Time      `json:"deleted_at,omitempty"` // Set on the deleted monitors, until they are restored or purged
 }
 
 type MonitorModel struct {
@@ -35,23 +54,109 @@ func NewMonitorModel(db *sql.DB) *MonitorModel {
 	return &MonitorModel{DB: db}
 }
 
+// MonitorInterface stores the monitors. The owner arguments are the email of the user making the
+// request, only the monitors of the organizations the user is a member of are found, whatever its
+// role. An empty owner matches all the monitors, it is used by the admin and by the background jobs.
+// The deleted monitors are only found by GetDeletedById, Restore and Purge.
 type MonitorInterface interface {
 	Create(ctx context.Context, monitor Monitor, log zerolog.Logger) (*Monitor, error)
-	GetById(ctx context.Context, id int64, log zerolog.Logger) (*Monitor, error)
-	Delete(ctx context.Context, id int64, log zerolog.Logger) error
+	GetById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error)
+	Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error
 	GetAll(ctx context.Context, log zerolog.Logger) ([]Monitor, error)
+	Update(ctx context.Context, monitor Monitor, owner string, log zerolog.Logger) (*Monitor, error)
+	List(ctx context.Context, filter MonitorFilter, log zerolog.Logger) ([]Monitor, string, error)
+	GetByURL(ctx context.Context, monitorType, url string, log zerolog.Logger) (*Monitor, error)
+	GetDeletedById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error)
+	Restore(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error)
+	Purge(ctx context.Context, deletedBefore time.Time, log zerolog.Logger) (int64, error)
 }
 
 var (
-	ErrUniqueConstraintViolation = errors.New("unique constraint violation")
-	ErrMonitorNotFound           = errors.New("monitor not found")
+	ErrUniqueConstraintViolation = &ConflictError{Resource: "monitor", Reason: "a monitor with the same org_id, type, url and method already exists"}
+	ErrMonitorNotFound           = &NotFoundError{Resource: "monitor"}
+	ErrInvalidHeaders            = NewValidationError("headers", "must be a JSON object with string values")
+	ErrInvalidParameters         = NewValidationError("parameters", "must be a JSON object with string values")
 )
 
+// ValidateMonitor verifies the fields shared by all the monitor types, adding an error to the
+// validator for each invalid field. The fields specific to the type are verified by its checker.
+func ValidateMonitor(v *validator.Validator, monitor Monitor) {
+	v.Check(monitor.OrgID > 0, "org_id", "is required")
+
+	v.Check(monitor.UserEmail != "", "user_email", "is required")
+	v.Check(validator.IsEmail(monitor.UserEmail), "user_email", "must be a valid email address")
+
+	v.Check(monitor.MonitorType != "", "type", "is required")
+
+	v.Check(monitor.URL != "", "url", "is required")
+	v.Check(len(monitor.URL) <= MaxURLLength, "url", fmt.Sprintf("must not be longer than %d bytes", MaxURLLength))
+
+	v.Check(len(monitor.Method) <= MaxMethodLength, "method", fmt.Sprintf("must not be longer than %d bytes", MaxMethodLength))
+
+	v.Check(monitor.FrequencyMinutes >= MinFrequencyMinutes && monitor.FrequencyMinutes <= MaxFrequencyMinutes,
+		"frequency_minutes", fmt.Sprintf("must be between %d and %d", MinFrequencyMinutes, MaxFrequencyMinutes))
+	v.Check(monitor.ThresholdMinutes >= MinThresholdMinutes && monitor.ThresholdMinutes <= MaxThresholdMinutes,
+		"threshold_minutes", fmt.Sprintf("must be between %d and %d", MinThresholdMinutes, MaxThresholdMinutes))
+
+	v.Check(len(monitor.Body) <= MaxBodyLength, "body", fmt.Sprintf("must not be longer than %d bytes", MaxBodyLength))
+	v.Check(len(monitor.Headers) <= MaxKeyValuesLength, "headers", fmt.Sprintf("must not be longer than %d bytes", MaxKeyValuesLength))
+	v.Check(len(monitor.Parameters) <= MaxKeyValuesLength, "parameters", fmt.Sprintf("must not be longer than %d bytes", MaxKeyValuesLength))
+	v.Check(len(monitor.Description) <= MaxDescriptionLength, "description", fmt.Sprintf("must not be longer than %d bytes", MaxDescriptionLength))
+	if len(monitor.Config) > 0 {
+		var config map[string]interface{}
+		v.Check(json.Unmarshal(monitor.Config, &config) == nil, "config", "must be a JSON object")
+	}
+}
+
+// configJSON returns the config as text, so it can be stored in the jsonb column.
+func (m Monitor) configJSON() string {
+	if len(m.Config) == 0 {
+		return "{}"
+	}
+	return string(m.Config)
+}
+
+// The Headers and Parameters fields are stored as text, encoded as a JSON object that maps each
+// name to a single string value, e.g. {"Authorization": "Bearer token", "Accept": "text/plain"}.
+// An empty string means that the monitor has no headers/parameters.
+
+// HeaderMap decodes the Headers field of the monitor.
+func (m Monitor) HeaderMap() (map[string]string, error) {
+	values, err := decodeKeyValues(m.Headers)
+	if err != nil {
+		return nil, ErrInvalidHeaders
+	}
+	return values, nil
+}
+
+// ParameterMap decodes the Parameters field of the monitor, that are the query parameters added
+// to the URL when the check is executed.
+func (m Monitor) ParameterMap() (map[string]string, error) {
+	values, err := decodeKeyValues(m.Parameters)
+	if err != nil {
+		return nil, ErrInvalidParameters
+	}
+	return values, nil
+}
+
+func decodeKeyValues(s string) (map[string]string, error) {
+	values := map[string]string{}
+	if s == "" {
+		return values, nil
+	}
+	err := json.Unmarshal([]byte(s), &values)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 func (m *MonitorModel) GetAll(ctx context.Context, log zerolog.Logger) ([]Monitor, error) {
 	log.Info().Msg("Getting all monitors")
 	rows, err := m.DB.QueryContext(ctx, `
-		SELECT monitor_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes
-		FROM monitors`)
+		SELECT monitor_id, org_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config
+		FROM monitors
+		WHERE deleted_at IS NULL`)
 	if err != nil {
 		log.Err(err).Msg("Error getting all monitors")
 		return nil, err
@@ -62,7 +167,7 @@ func (m *MonitorModel) GetAll(ctx context.Context, log zerolog.Logger) ([]Monito
 
 	for rows.Next() {
 		var monitor Monitor
-		err := rows.Scan(&monitor.MonitorID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes)
+		err := rows.Scan(&monitor.MonitorID, &monitor.OrgID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes, &monitor.Config)
 		if err != nil {
 			log.Err(err).Msg("Error scanning rows")
 			return nil, err
@@ -73,16 +178,26 @@ func (m *MonitorModel) GetAll(ctx context.Context, log zerolog.Logger) ([]Monito
 	return monitors, nil
 }
 
-func (m *MonitorModel) Delete(ctx context.Context, id int64, log zerolog.Logger) error {
+// Delete marks the monitor as deleted, keeping it and its history until it is restored or purged.
+func (m *MonitorModel) Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error {
 	log.Info().Msg("Deleting monitor")
-	_, err := m.DB.ExecContext(ctx, `
-		DELETE FROM monitors
-		WHERE monitor_id = $1`,
-		id)
+	result, err := m.DB.ExecContext(ctx, `
+		UPDATE monitors
+		SET deleted_at = NOW()
+		WHERE monitor_id = $1 AND deleted_at IS NULL AND ($2 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $2))`,
+		id, owner)
 	if err != nil {
 		log.Err(err).Msg("Error deleting monitor")
 		return err
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the deleted rows")
+		return err
+	}
+	if rows == 0 {
+		return ErrMonitorNotFound
+	}
 	return nil
 
 }
@@ -93,16 +208,18 @@ func (m *MonitorModel) Create(ctx context.Context, monitor Monitor, log zerolog.
 	var psqlErr *pq.Error
 
 	err := m.DB.QueryRowContext(ctx, `
-		INSERT INTO monitors (user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,  $11)
+		INSERT INTO monitors (user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,  $11, $12, $13)
 		RETURNING monitor_id`,
-		monitor.UserEmail, monitor.MonitorType, monitor.URL, monitor.Method, monitor.UpdatedAt, monitor.Body, monitor.Headers, monitor.Parameters, monitor.Description, monitor.FrequencyMinutes, monitor.ThresholdMinutes).Scan(&id)
+		monitor.UserEmail, monitor.MonitorType, monitor.URL, monitor.Method, monitor.UpdatedAt, monitor.Body, monitor.Headers, monitor.Parameters, monitor.Description, monitor.FrequencyMinutes, monitor.ThresholdMinutes, monitor.configJSON(), monitor.OrgID).Scan(&id)
 	if err != nil {
 		log.Err(err).Msg("Error creating monitor")
 		//if erro is pq: duplicate key value violates unique constraint "monitors_pkey"
 		//then return a custom error
 		if errors.As(err, &psqlErr) && psqlErr.Code == "23505" { // 23505 is unique_violation
 			return nil, ErrUniqueConstraintViolation
+		} else if errors.As(err, &psqlErr) && psqlErr.Code == "23503" { // 23503 is foreign_key_violation
+			return nil, ErrOrgNotFound
 		} else {
 
 			return nil, err
@@ -112,14 +229,14 @@ func (m *MonitorModel) Create(ctx context.Context, monitor Monitor, log zerolog.
 	return &monitor, nil
 }
 
-func (m *MonitorModel) GetById(ctx context.Context, id int64, log zerolog.Logger) (*Monitor, error) {
+func (m *MonitorModel) GetById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error) {
 	log.Info().Msg("Getting monitor by id")
 	var monitor Monitor
 	err := m.DB.QueryRowContext(ctx, `
-		SELECT monitor_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes
+		SELECT monitor_id, org_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config
 		FROM monitors
-		WHERE monitor_id = $1`,
-		id).Scan(&monitor.MonitorID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes)
+		WHERE monitor_id = $1 AND deleted_at IS NULL AND ($2 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $2))`,
+		id, owner).Scan(&monitor.MonitorID, &monitor.OrgID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes, &monitor.Config)
 	if err != nil {
 		//verify if the error is pq: no rows in result set
 		if errors.Is(err, sql.ErrNoRows) {
@@ -132,3 +249,152 @@ func (m *MonitorModel) GetById(ctx context.Context, id int64, log zerolog.Logger
 	}
 	return &monitor, nil
 }
+
+// GetByURL returns the monitor of the type with the URL. It is used by the types whose URL is
+// unique, like the ping URL of the heartbeat monitors.
+func (m *MonitorModel) GetByURL(ctx context.Context, monitorType, url string, log zerolog.Logger) (*Monitor, error) {
+	log.Info().Msg("Getting monitor by url")
+	var monitor Monitor
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT monitor_id, org_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config
+		FROM monitors
+		WHERE type = $1 AND url = $2 AND deleted_at IS NULL
+		ORDER BY monitor_id
+		LIMIT 1`,
+		monitorType, url).Scan(&monitor.MonitorID, &monitor.OrgID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes, &monitor.Config)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMonitorNotFound
+		}
+		log.Err(err).Msg("Error getting monitor by url")
+		return nil, err
+	}
+	return &monitor, nil
+}
+
+// Update replaces all the fields of the monitor identified by monitor.MonitorID.
+func (m *MonitorModel) Update(ctx context.Context, monitor Monitor, owner string, log zerolog.Logger) (*Monitor, error) {
+	log.Info().Msg("Updating monitor")
+	var psqlErr *pq.Error
+
+	result, err := m.DB.ExecContext(ctx, `
+		UPDATE monitors
+		SET user_email = $2, type = $3, url = $4, method = $5, updated_at = $6, body = $7, headers = $8, parameters = $9, description = $10, frequency_minutes = $11, threshold_minutes = $12, config = $13, org_id = $15
+		WHERE monitor_id = $1 AND deleted_at IS NULL AND ($14 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $14))`,
+		monitor.MonitorID, monitor.UserEmail, monitor.MonitorType, monitor.URL, monitor.Method, monitor.UpdatedAt, monitor.Body, monitor.Headers, monitor.Parameters, monitor.Description, monitor.FrequencyMinutes, monitor.ThresholdMinutes, monitor.configJSON(), owner, monitor.OrgID)
+	if err != nil {
+		log.Err(err).Msg("Error updating monitor")
+		//the new organization, type, url and method can conflict with another monitor
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23505" { // 23505 is unique_violation
+			return nil, ErrUniqueConstraintViolation
+		}
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23503" { // 23503 is foreign_key_violation
+			return nil, ErrOrgNotFound
+		}
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the updated rows")
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrMonitorNotFound
+	}
+	return &monitor, nil
+}
+
+// GetDeletedById returns the deleted monitor, that can be restored until it is purged.
+func (m *MonitorModel) GetDeletedById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error) {
+	log.Info().Msg("Getting deleted monitor by id")
+	var monitor Monitor
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT monitor_id, org_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config, deleted_at
+		FROM monitors
+		WHERE monitor_id = $1 AND deleted_at IS NOT NULL AND ($2 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $2))`,
+		id, owner).Scan(&monitor.MonitorID, &monitor.OrgID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes, &monitor.Config, &monitor.DeletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMonitorNotFound
+		}
+		log.Err(err).Msg("Error getting deleted monitor by id")
+		return nil, err
+	}
+	return &monitor, nil
+}
+
+// Restore undoes the deletion of the monitor. It fails with ErrUniqueConstraintViolation when
+// another monitor with the same org_id, type, url and method was created in the meantime.
+func (m *MonitorModel) Restore(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error) {
+	log.Info().Msg("Restoring monitor")
+	var psqlErr *pq.Error
+	var monitor Monitor
+	err := m.DB.QueryRowContext(ctx, `
+		UPDATE monitors
+		SET deleted_at = NULL
+		WHERE monitor_id = $1 AND deleted_at IS NOT NULL AND ($2 = '' OR org_id IN (SELECT org_id FROM org_roles WHERE user_email = $2))
+		RETURNING monitor_id, org_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config`,
+		id, owner).Scan(&monitor.MonitorID, &monitor.OrgID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes, &monitor.Config)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMonitorNotFound
+		}
+		log.Err(err).Msg("Error restoring monitor")
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23505" { // 23505 is unique_violation
+			return nil, ErrUniqueConstraintViolation
+		}
+		return nil, err
+	}
+	return &monitor, nil
+}
+
+// Purge removes for good the monitors deleted before the given time, with their check results,
+// incidents, pings and links to the channels. It returns the number of monitors removed.
+func (m *MonitorModel) Purge(ctx context.Context, deletedBefore time.Time, log zerolog.Logger) (int64, error) {
+	log.Info().Msg("Purging deleted monitors")
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Err(err).Msg("Error starting the transaction")
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	purged, err := purgeMonitors(ctx, tx, "deleted_at < $1", deletedBefore)
+	if err != nil {
+		log.Err(err).Msg("Error purging deleted monitors")
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		log.Err(err).Msg("Error committing the transaction")
+		return 0, err
+	}
+	return purged, nil
+}
+
+// purgeMonitors removes the deleted monitors matching the condition, whose only argument is arg,
+// and their history.
+func purgeMonitors(ctx context.Context, tx *sql.Tx, condition string, arg interface{}) (int64, error) {
+	var ids []int64
+	err := tx.QueryRowContext(ctx, `
+		WITH purged AS (
+			DELETE FROM monitors
+			WHERE deleted_at IS NOT NULL AND `+condition+`
+			RETURNING monitor_id
+		)
+		SELECT COALESCE(array_agg(monitor_id), '{}') FROM purged`,
+		arg).Scan(pq.Array(&ids))
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	//the history only references the monitors by id, without foreign keys
+	for _, table := range []string{"check_results", "incidents", "pings", "monitor_channels"} {
+		_, err = tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE monitor_id = ANY($1)`, pq.Array(ids))
+		if err != nil {
+			return 0, fmt.Errorf("purging %s: %w", table, err)
+		}
+	}
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,168 @@
+// This file contains the cursor based pagination, filtering and sorting of the monitors.
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultMonitorLimit = 50
+	MaxMonitorLimit     = 1000
+)
+
+// MonitorSortValues are the accepted values of MonitorFilter.Sort, a leading "-" sorts descending.
+var MonitorSortValues = []string{"monitor_id", "-monitor_id", "updated_at", "-updated_at"}
+
+var ErrInvalidCursor = NewValidationError("cursor", "invalid cursor")
+
+func ValidMonitorSort(sort string) bool {
+	for _, value := range MonitorSortValues {
+		if sort == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MonitorFilter limits and orders the monitors returned by List. Empty fields are ignored.
+type MonitorFilter struct {
+	Owner       string // Email of the user making the request, see MonitorInterface
+	OrgID       int64
+	UserEmail   string
+	MonitorType string
+	Method      string
+	URLContains string
+	Sort        string
+	Limit       int
+	Cursor      string // Cursor returned by the previous call to List
+}
+
+// cursor points to the last monitor of a page, the next page starts right after it.
+type cursor struct {
+	Sort      string    `json:"s"`
+	UpdatedAt time.Time `json:"u,omitempty"`
+	MonitorID int64     `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(js, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// List returns a page of monitors and the cursor of the next page, that is empty on the last page.
+func (m *MonitorModel) List(ctx context.Context, filter MonitorFilter, log zerolog.Logger) ([]Monitor, string, error) {
+	log.Info().Msg("Listing monitors")
+	if filter.Sort == "" {
+		filter.Sort = "monitor_id"
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultMonitorLimit
+	}
+	column := strings.TrimPrefix(filter.Sort, "-")
+	direction, comparison := "ASC", ">"
+	if strings.HasPrefix(filter.Sort, "-") {
+		direction, comparison = "DESC", "<"
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Owner != "" {
+		addCondition("org_id IN (SELECT org_id FROM org_roles WHERE user_email = $%d)", filter.Owner)
+	}
+	if filter.OrgID != 0 {
+		addCondition("org_id = $%d", filter.OrgID)
+	}
+	if filter.UserEmail != "" {
+		addCondition("user_email = $%d", filter.UserEmail)
+	}
+	if filter.MonitorType != "" {
+		addCondition("type = $%d", filter.MonitorType)
+	}
+	if filter.Method != "" {
+		addCondition("method = $%d", filter.Method)
+	}
+	if filter.URLContains != "" {
+		addCondition("strpos(url, $%d) > 0", filter.URLContains)
+	}
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil || c.Sort != filter.Sort {
+			return nil, "", ErrInvalidCursor
+		}
+		if column == "updated_at" {
+			args = append(args, c.UpdatedAt, c.MonitorID)
+			conditions = append(conditions, fmt.Sprintf("(updated_at, monitor_id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+		} else {
+			addCondition("monitor_id "+comparison+" $%d", c.MonitorID)
+		}
+	}
+
+	query := `
+		SELECT monitor_id, org_id, user_email, type, url, method, updated_at, body, headers, parameters, description, frequency_minutes, threshold_minutes, config
+		FROM monitors`
+	query += "\n\t\tWHERE " + strings.Join(conditions, " AND ")
+	if column == "updated_at" {
+		query += fmt.Sprintf("\n\t\tORDER BY updated_at %s, monitor_id %s", direction, direction)
+	} else {
+		query += fmt.Sprintf("\n\t\tORDER BY monitor_id %s", direction)
+	}
+	//one more row is fetched to know if there is a next page
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf("\n\t\tLIMIT $%d", len(args))
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Err(err).Msg("Error listing monitors")
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	monitors := []Monitor{}
+	for rows.Next() {
+		var monitor Monitor
+		err := rows.Scan(&monitor.MonitorID, &monitor.OrgID, &monitor.UserEmail, &monitor.MonitorType, &monitor.URL, &monitor.Method, &monitor.UpdatedAt, &monitor.Body, &monitor.Headers, &monitor.Parameters, &monitor.Description, &monitor.FrequencyMinutes, &monitor.ThresholdMinutes, &monitor.Config)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, "", err
+		}
+		monitors = append(monitors, monitor)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, "", err
+	}
+
+	if len(monitors) <= filter.Limit {
+		return monitors, "", nil
+	}
+	monitors = monitors[:filter.Limit]
+	last := monitors[len(monitors)-1]
+	next := cursor{Sort: filter.Sort, MonitorID: last.MonitorID}
+	if column == "updated_at" {
+		next.UpdatedAt = last.UpdatedAt
+	}
+	return monitors, encodeCursor(next), nil
+}
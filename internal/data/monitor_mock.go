@@ -3,6 +3,7 @@ package data
 
 import (
 	"context"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
@@ -18,9 +19,15 @@ func NewMonitorModelMock() *MonitorModelMock {
 
 type MockMonitorInterface interface {
 	Create(ctx context.Context, monitor Monitor, log zerolog.Logger) (*Monitor, error)
-	GetById(ctx context.Context, id int64, log zerolog.Logger) (*Monitor, error)
-	Delete(ctx context.Context, id int64, log zerolog.Logger) error
+	GetById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error)
+	Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error
 	GetAll(ctx context.Context, log zerolog.Logger) ([]Monitor, error)
+	Update(ctx context.Context, monitor Monitor, owner string, log zerolog.Logger) (*Monitor, error)
+	List(ctx context.Context, filter MonitorFilter, log zerolog.Logger) ([]Monitor, string, error)
+	GetByURL(ctx context.Context, monitorType, url string, log zerolog.Logger) (*Monitor, error)
+	GetDeletedById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error)
+	Restore(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error)
+	Purge(ctx context.Context, deletedBefore time.Time, log zerolog.Logger) (int64, error)
 }
 
 func (m *MonitorModelMock) GetAll(ctx context.Context, log zerolog.Logger) ([]Monitor, error) {
@@ -33,12 +40,42 @@ func (m *MonitorModelMock) Create(ctx context.Context, monitor Monitor, log zero
 	return args.Get(0).(*Monitor), args.Error(1)
 }
 
-func (m *MonitorModelMock) GetById(ctx context.Context, id int64, log zerolog.Logger) (*Monitor, error) {
-	args := m.Called(ctx, id, log)
+func (m *MonitorModelMock) GetById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error) {
+	args := m.Called(ctx, id, owner, log)
 	return args.Get(0).(*Monitor), args.Error(1)
 }
 
-func (m *MonitorModelMock) Delete(ctx context.Context, id int64, log zerolog.Logger) error {
-	args := m.Called(ctx, id, log)
+func (m *MonitorModelMock) Delete(ctx context.Context, id int64, owner string, log zerolog.Logger) error {
+	args := m.Called(ctx, id, owner, log)
 	return args.Error(0)
 }
+
+func (m *MonitorModelMock) Update(ctx context.Context, monitor Monitor, owner string, log zerolog.Logger) (*Monitor, error) {
+	args := m.Called(ctx, monitor, owner, log)
+	return args.Get(0).(*Monitor), args.Error(1)
+}
+
+func (m *MonitorModelMock) List(ctx context.Context, filter MonitorFilter, log zerolog.Logger) ([]Monitor, string, error) {
+	args := m.Called(ctx, filter, log)
+	return args.Get(0).([]Monitor), args.String(1), args.Error(2)
+}
+
+func (m *MonitorModelMock) GetByURL(ctx context.Context, monitorType, url string, log zerolog.Logger) (*Monitor, error) {
+	args := m.Called(ctx, monitorType, url, log)
+	return args.Get(0).(*Monitor), args.Error(1)
+}
+
+func (m *MonitorModelMock) GetDeletedById(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error) {
+	args := m.Called(ctx, id, owner, log)
+	return args.Get(0).(*Monitor), args.Error(1)
+}
+
+func (m *MonitorModelMock) Restore(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Monitor, error) {
+	args := m.Called(ctx, id, owner, log)
+	return args.Get(0).(*Monitor), args.Error(1)
+}
+
+func (m *MonitorModelMock) Purge(ctx context.Context, deletedBefore time.Time, log zerolog.Logger) (int64, error) {
+	args := m.Called(ctx, deletedBefore, log)
+	return args.Get(0).(int64), args.Error(1)
+}
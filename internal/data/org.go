@@ -0,0 +1,528 @@
+// This file contains the organizations, that own the monitors, and the roles of their members. A
+// user is a member of an organization directly or through its teams, and has the highest of the
+// roles given by its membership and its teams.
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/validator"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+// Role is what the members of an organization can do with its monitors and its members.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // Lists and reads the monitors
+	RoleEditor Role = "editor" // Creates, changes and deletes the monitors
+	RoleOwner  Role = "owner"  // Manages the members and the teams of the organization
+)
+
+var Roles = []string{string(RoleViewer), string(RoleEditor), string(RoleOwner)}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// Includes reports whether the role grants all the permissions of the other role.
+func (r Role) Includes(other Role) bool {
+	return r.rank() > 0 && r.rank() >= other.rank()
+}
+
+const (
+	MaxOrgNameLength  = 100
+	MaxTeamNameLength = 100
+)
+
+type Org struct {
+	OrgID     int64     `json:"org_id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role,omitempty"` // Role of the user of the request, empty for the admin
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrgMember struct {
+	OrgID     int64     `json:"org_id"`
+	UserEmail string    `json:"user_email"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Team gives its role in the organization to all its members.
+type Team struct {
+	TeamID    int64     `json:"team_id"`
+	OrgID     int64     `json:"org_id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	Members   []string  `json:"members"` // Emails of the members
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrgModel struct {
+	DB *sql.DB
+}
+
+func NewOrgModel(db *sql.DB) *OrgModel {
+	return &OrgModel{DB: db}
+}
+
+// OrgInterface stores the organizations, their members and their teams. The owner arguments are
+// the email of the user making the request, only the organizations the user is a member of are
+// found. An empty owner matches all the organizations, it is used by the admin.
+type OrgInterface interface {
+	Insert(ctx context.Context, org Org, ownerEmail string, log zerolog.Logger) (*Org, error)
+	GetByID(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Org, error)
+	GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]Org, error)
+	Delete(ctx context.Context, id int64, log zerolog.Logger) error
+	GetRole(ctx context.Context, orgID int64, userEmail string, log zerolog.Logger) (Role, error)
+	GetMembers(ctx context.Context, orgID int64, log zerolog.Logger) ([]OrgMember, error)
+	SetMember(ctx context.Context, member OrgMember, log zerolog.Logger) (*OrgMember, error)
+	RemoveMember(ctx context.Context, orgID int64, userEmail string, log zerolog.Logger) error
+	InsertTeam(ctx context.Context, team Team, log zerolog.Logger) (*Team, error)
+	GetTeams(ctx context.Context, orgID int64, log zerolog.Logger) ([]Team, error)
+	DeleteTeam(ctx context.Context, orgID, teamID int64, log zerolog.Logger) error
+	AddTeamMember(ctx context.Context, orgID, teamID int64, userEmail string, log zerolog.Logger) error
+	RemoveTeamMember(ctx context.Context, orgID, teamID int64, userEmail string, log zerolog.Logger) error
+}
+
+var (
+	ErrOrgNotFound        = &NotFoundError{Resource: "organization"}
+	ErrOrgMemberNotFound  = &NotFoundError{Resource: "member of the organization"}
+	ErrTeamNotFound       = &NotFoundError{Resource: "team"}
+	ErrTeamMemberNotFound = &NotFoundError{Resource: "member of the team"}
+	ErrOrgHasMonitors     = &ConflictError{Resource: "organization", Reason: "the organization still has monitors, delete them first"}
+	ErrLastOrgOwner       = &ConflictError{Resource: "organization", Reason: "the organization must keep at least one owner"}
+	ErrDuplicateTeam      = &ConflictError{Resource: "team", Reason: "a team with the same name already exists in the organization"}
+)
+
+func ValidateOrg(v *validator.Validator, org Org) {
+	v.Check(org.Name != "", "name", "is required")
+	v.Check(len(org.Name) <= MaxOrgNameLength, "name", fmt.Sprintf("must not be longer than %d bytes", MaxOrgNameLength))
+}
+
+func ValidateOrgMember(v *validator.Validator, member OrgMember) {
+	v.Check(validator.IsEmail(member.UserEmail), "user_email", "must be a valid email address")
+	v.Check(validator.PermittedValue(string(member.Role), Roles...), "role", "must be one of viewer, editor, owner")
+}
+
+func ValidateTeam(v *validator.Validator, team Team) {
+	v.Check(team.Name != "", "name", "is required")
+	v.Check(len(team.Name) <= MaxTeamNameLength, "name", fmt.Sprintf("must not be longer than %d bytes", MaxTeamNameLength))
+	v.Check(validator.PermittedValue(string(team.Role), Roles...), "role", "must be one of viewer, editor, owner")
+}
+
+// Insert creates the organization with the user as its first owner.
+func (m *OrgModel) Insert(ctx context.Context, org Org, ownerEmail string, log zerolog.Logger) (*Org, error) {
+	log.Info().Msg("Creating organization")
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Err(err).Msg("Error starting the transaction")
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO organizations (name)
+		VALUES ($1)
+		RETURNING org_id, created_at`,
+		org.Name).Scan(&org.OrgID, &org.CreatedAt)
+	if err != nil {
+		log.Err(err).Msg("Error creating organization")
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO org_members (org_id, user_email, role)
+		VALUES ($1, $2, $3)`,
+		org.OrgID, ownerEmail, RoleOwner)
+	if err != nil {
+		log.Err(err).Msg("Error adding the owner of the organization")
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		log.Err(err).Msg("Error committing the transaction")
+		return nil, err
+	}
+	org.Role = RoleOwner
+	return &org, nil
+}
+
+// orgRole is the role of the user $2 in each organization, the highest of its membership and its
+// teams. It is NULL when the owner argument is empty.
+const orgRole = `
+		(SELECT role FROM org_roles r WHERE r.org_id = o.org_id AND r.user_email = $2
+		ORDER BY CASE role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC LIMIT 1)`
+
+func (m *OrgModel) GetByID(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Org, error) {
+	log.Info().Msg("Getting organization by id")
+	var org Org
+	var role sql.NullString
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT o.org_id, o.name, o.created_at,`+orgRole+`
+		FROM organizations o
+		WHERE o.org_id = $1`,
+		id, owner).Scan(&org.OrgID, &org.Name, &org.CreatedAt, &role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrgNotFound
+		}
+		log.Err(err).Msg("Error getting organization by id")
+		return nil, err
+	}
+	if owner != "" && !role.Valid {
+		return nil, ErrOrgNotFound
+	}
+	org.Role = Role(role.String)
+	return &org, nil
+}
+
+func (m *OrgModel) GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]Org, error) {
+	log.Info().Msg("Getting organizations")
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT org_id, name, created_at, role
+		FROM (
+			SELECT o.org_id, o.name, o.created_at,`+orgRole+` AS role
+			FROM organizations o
+			WHERE $1 = '' OR o.org_id IN (SELECT org_id FROM org_roles WHERE user_email = $1)
+		) o
+		ORDER BY org_id`,
+		owner, owner)
+	if err != nil {
+		log.Err(err).Msg("Error getting organizations")
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []Org{}
+	for rows.Next() {
+		var org Org
+		var role sql.NullString
+		if err := rows.Scan(&org.OrgID, &org.Name, &org.CreatedAt, &role); err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		org.Role = Role(role.String)
+		orgs = append(orgs, org)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// Delete removes the organization with its members and teams, and purges its deleted monitors. It
+// fails with ErrOrgHasMonitors while it owns monitors that are not deleted.
+func (m *OrgModel) Delete(ctx context.Context, id int64, log zerolog.Logger) error {
+	log.Info().Msg("Deleting organization")
+	var psqlErr *pq.Error
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Err(err).Msg("Error starting the transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = purgeMonitors(ctx, tx, "org_id = $1", id); err != nil {
+		log.Err(err).Msg("Error purging the deleted monitors of the organization")
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM organizations
+		WHERE org_id = $1`,
+		id)
+	if err != nil {
+		if errors.As(err, &psqlErr) && psqlErr.Code == "23503" { // 23503 is foreign_key_violation
+			return ErrOrgHasMonitors
+		}
+		log.Err(err).Msg("Error deleting organization")
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the deleted rows")
+		return err
+	}
+	if rows == 0 {
+		return ErrOrgNotFound
+	}
+	if err = tx.Commit(); err != nil {
+		log.Err(err).Msg("Error committing the transaction")
+		return err
+	}
+	return nil
+}
+
+// GetRole returns the role of the user in the organization, failing with ErrOrgNotFound when the
+// user is not a member.
+func (m *OrgModel) GetRole(ctx context.Context, orgID int64, userEmail string, log zerolog.Logger) (Role, error) {
+	var role Role
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT role FROM org_roles
+		WHERE org_id = $1 AND user_email = $2
+		ORDER BY CASE role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC
+		LIMIT 1`,
+		orgID, userEmail).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrOrgNotFound
+		}
+		log.Err(err).Msg("Error getting the role in the organization")
+		return "", err
+	}
+	return role, nil
+}
+
+// GetMembers returns the direct members of the organization, without the members of its teams.
+func (m *OrgModel) GetMembers(ctx context.Context, orgID int64, log zerolog.Logger) ([]OrgMember, error) {
+	log.Info().Msg("Getting members of organization")
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT org_id, user_email, role, created_at
+		FROM org_members
+		WHERE org_id = $1
+		ORDER BY user_email`,
+		orgID)
+	if err != nil {
+		log.Err(err).Msg("Error getting members of organization")
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []OrgMember{}
+	for rows.Next() {
+		var member OrgMember
+		if err := rows.Scan(&member.OrgID, &member.UserEmail, &member.Role, &member.CreatedAt); err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return members, nil
+}
+
+// SetMember adds the user to the organization or changes its role. It fails with ErrLastOrgOwner
+// when it would leave the organization without owners.
+func (m *OrgModel) SetMember(ctx context.Context, member OrgMember, log zerolog.Logger) (*OrgMember, error) {
+	log.Info().Msg("Setting member of organization")
+	err := m.changeMembers(ctx, member.OrgID, member.UserEmail, member.Role, log, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO org_members (org_id, user_email, role)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (org_id, user_email) DO UPDATE SET role = EXCLUDED.role
+			RETURNING created_at`,
+			member.OrgID, member.UserEmail, member.Role).Scan(&member.CreatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember removes the user from the organization, but not from its teams. It fails with
+// ErrLastOrgOwner when the user is the last owner.
+func (m *OrgModel) RemoveMember(ctx context.Context, orgID int64, userEmail string, log zerolog.Logger) error {
+	log.Info().Msg("Removing member of organization")
+	return m.changeMembers(ctx, orgID, userEmail, "", log, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			DELETE FROM org_members
+			WHERE org_id = $1 AND user_email = $2`,
+			orgID, userEmail)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrOrgMemberNotFound
+		}
+		return nil
+	})
+}
+
+// changeMembers runs change in a transaction that locks the organization, after verifying that
+// giving the new role to the user, empty when it is removed, keeps an owner in the organization.
+func (m *OrgModel) changeMembers(ctx context.Context, orgID int64, userEmail string, newRole Role, log zerolog.Logger, change func(tx *sql.Tx) error) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Err(err).Msg("Error starting the transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	//the lock serializes the changes of the members, so two owners can not remove each other
+	err = tx.QueryRowContext(ctx, `SELECT org_id FROM organizations WHERE org_id = $1 FOR UPDATE`, orgID).Scan(&orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrgNotFound
+		}
+		log.Err(err).Msg("Error locking the organization")
+		return err
+	}
+	if newRole != RoleOwner {
+		var otherOwners int
+		err = tx.QueryRowContext(ctx, `
+			SELECT count(*) FROM org_members
+			WHERE org_id = $1 AND role = 'owner' AND user_email <> $2`,
+			orgID, userEmail).Scan(&otherOwners)
+		if err != nil {
+			log.Err(err).Msg("Error counting the owners of the organization")
+			return err
+		}
+		if otherOwners == 0 {
+			return ErrLastOrgOwner
+		}
+	}
+	if err = change(tx); err != nil {
+		var notFoundErr *NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			log.Err(err).Msg("Error changing the members of the organization")
+		}
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		log.Err(err).Msg("Error committing the transaction")
+		return err
+	}
+	return nil
+}
+
+func (m *OrgModel) InsertTeam(ctx context.Context, team Team, log zerolog.Logger) (*Team, error) {
+	log.Info().Msg("Creating team")
+	var psqlErr *pq.Error
+	err := m.DB.QueryRowContext(ctx, `
+		INSERT INTO teams (org_id, name, role)
+		VALUES ($1, $2, $3)
+		RETURNING team_id, created_at`,
+		team.OrgID, team.Name, team.Role).Scan(&team.TeamID, &team.CreatedAt)
+	if err != nil {
+		if errors.As(err, &psqlErr) {
+			switch psqlErr.Code {
+			case "23505": // 23505 is unique_violation
+				return nil, ErrDuplicateTeam
+			case "23503": // 23503 is foreign_key_violation
+				return nil, ErrOrgNotFound
+			}
+		}
+		log.Err(err).Msg("Error creating team")
+		return nil, err
+	}
+	team.Members = []string{}
+	return &team, nil
+}
+
+func (m *OrgModel) GetTeams(ctx context.Context, orgID int64, log zerolog.Logger) ([]Team, error) {
+	log.Info().Msg("Getting teams of organization")
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT t.team_id, t.org_id, t.name, t.role, t.created_at,
+			COALESCE(array_agg(tm.user_email ORDER BY tm.user_email) FILTER (WHERE tm.user_email IS NOT NULL), '{}')
+		FROM teams t
+		LEFT JOIN team_members tm ON tm.team_id = t.team_id
+		WHERE t.org_id = $1
+		GROUP BY t.team_id
+		ORDER BY t.team_id`,
+		orgID)
+	if err != nil {
+		log.Err(err).Msg("Error getting teams of organization")
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []Team{}
+	for rows.Next() {
+		var team Team
+		if err := rows.Scan(&team.TeamID, &team.OrgID, &team.Name, &team.Role, &team.CreatedAt, pq.Array(&team.Members)); err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return teams, nil
+}
+
+// DeleteTeam removes the team of the organization, its members lose the role of the team.
+func (m *OrgModel) DeleteTeam(ctx context.Context, orgID, teamID int64, log zerolog.Logger) error {
+	log.Info().Msg("Deleting team")
+	result, err := m.DB.ExecContext(ctx, `
+		DELETE FROM teams
+		WHERE team_id = $1 AND org_id = $2`,
+		teamID, orgID)
+	return affectedOne(result, err, ErrTeamNotFound, "Error deleting team", log)
+}
+
+// AddTeamMember adds the user to the team of the organization. Adding it again does nothing.
+func (m *OrgModel) AddTeamMember(ctx context.Context, orgID, teamID int64, userEmail string, log zerolog.Logger) error {
+	log.Info().Msg("Adding member to team")
+	result, err := m.DB.ExecContext(ctx, `
+		INSERT INTO team_members (team_id, user_email)
+		SELECT team_id, $3 FROM teams
+		WHERE team_id = $1 AND org_id = $2
+		ON CONFLICT DO NOTHING`,
+		teamID, orgID, userEmail)
+	if err != nil {
+		log.Err(err).Msg("Error adding member to team")
+		return err
+	}
+	//nothing is inserted when the team does not exist or the user is already a member
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		var exists bool
+		err = m.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE team_id = $1 AND org_id = $2)`, teamID, orgID).Scan(&exists)
+		if err != nil {
+			log.Err(err).Msg("Error getting team")
+			return err
+		}
+		if !exists {
+			return ErrTeamNotFound
+		}
+	}
+	return nil
+}
+
+func (m *OrgModel) RemoveTeamMember(ctx context.Context, orgID, teamID int64, userEmail string, log zerolog.Logger) error {
+	log.Info().Msg("Removing member from team")
+	result, err := m.DB.ExecContext(ctx, `
+		DELETE FROM team_members tm
+		USING teams t
+		WHERE tm.team_id = t.team_id AND t.team_id = $1 AND t.org_id = $2 AND tm.user_email = $3`,
+		teamID, orgID, userEmail)
+	return affectedOne(result, err, ErrTeamMemberNotFound, "Error removing member from team", log)
+}
+
+// affectedOne returns notFound when the statement did not change any row.
+func affectedOne(result sql.Result, err error, notFound error, message string, log zerolog.Logger) error {
+	if err != nil {
+		log.Err(err).Msg(message)
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Err(err).Msg("Error getting the affected rows")
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type OrgModelMock struct {
+	mock.Mock
+}
+
+func NewOrgModelMock() *OrgModelMock {
+	return &OrgModelMock{}
+}
+
+func (m *OrgModelMock) Insert(ctx context.Context, org Org, ownerEmail string, log zerolog.Logger) (*Org, error) {
+	args := m.Called(ctx, org, ownerEmail, log)
+	return args.Get(0).(*Org), args.Error(1)
+}
+
+func (m *OrgModelMock) GetByID(ctx context.Context, id int64, owner string, log zerolog.Logger) (*Org, error) {
+	args := m.Called(ctx, id, owner, log)
+	return args.Get(0).(*Org), args.Error(1)
+}
+
+func (m *OrgModelMock) GetAll(ctx context.Context, owner string, log zerolog.Logger) ([]Org, error) {
+	args := m.Called(ctx, owner, log)
+	return args.Get(0).([]Org), args.Error(1)
+}
+
+func (m *OrgModelMock) Delete(ctx context.Context, id int64, log zerolog.Logger) error {
+	args := m.Called(ctx, id, log)
+	return args.Error(0)
+}
+
+func (m *OrgModelMock) GetRole(ctx context.Context, orgID int64, userEmail string, log zerolog.Logger) (Role, error) {
+	args := m.Called(ctx, orgID, userEmail, log)
+	return args.Get(0).(Role), args.Error(1)
+}
+
+func (m *OrgModelMock) GetMembers(ctx context.Context, orgID int64, log zerolog.Logger) ([]OrgMember, error) {
+	args := m.Called(ctx, orgID, log)
+	return args.Get(0).([]OrgMember), args.Error(1)
+}
+
+func (m *OrgModelMock) SetMember(ctx context.Context, member OrgMember, log zerolog.Logger) (*OrgMember, error) {
+	args := m.Called(ctx, member, log)
+	return args.Get(0).(*OrgMember), args.Error(1)
+}
+
+func (m *OrgModelMock) RemoveMember(ctx context.Context, orgID int64, userEmail string, log zerolog.Logger) error {
+	args := m.Called(ctx, orgID, userEmail, log)
+	return args.Error(0)
+}
+
+func (m *OrgModelMock) InsertTeam(ctx context.Context, team Team, log zerolog.Logger) (*Team, error) {
+	args := m.Called(ctx, team, log)
+	return args.Get(0).(*Team), args.Error(1)
+}
+
+func (m *OrgModelMock) GetTeams(ctx context.Context, orgID int64, log zerolog.Logger) ([]Team, error) {
+	args := m.Called(ctx, orgID, log)
+	return args.Get(0).([]Team), args.Error(1)
+}
+
+func (m *OrgModelMock) DeleteTeam(ctx context.Context, orgID, teamID int64, log zerolog.Logger) error {
+	args := m.Called(ctx, orgID, teamID, log)
+	return args.Error(0)
+}
+
+func (m *OrgModelMock) AddTeamMember(ctx context.Context, orgID, teamID int64, userEmail string, log zerolog.Logger) error {
+	args := m.Called(ctx, orgID, teamID, userEmail, log)
+	return args.Error(0)
+}
+
+func (m *OrgModelMock) RemoveTeamMember(ctx context.Context, orgID, teamID int64, userEmail string, log zerolog.Logger) error {
+	args := m.Called(ctx, orgID, teamID, userEmail, log)
+	return args.Error(0)
+}
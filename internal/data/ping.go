@@ -0,0 +1,129 @@
+// This file contains the Ping struct, that is a signal sent by a job to its heartbeat monitor, and
+// the functions to store the pings and get the last ones of a monitor.
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Kinds of ping. A job can ping only when it succeeds, or ping when it starts and when it
+// finishes, so its duration is measured.
+const (
+	PingStart   = "start"
+	PingSuccess = "success"
+	PingFail    = "fail"
+)
+
+// MaxPingMessageLength is the max length of the message stored with a ping, that is the body of
+// the request. Longer bodies are truncated.
+const MaxPingMessageLength = 1024
+
+type Ping struct {
+	PingID     int64     `json:"ping_id"`
+	MonitorID  int64     `json:"monitor_id"`
+	Kind       string    `json:"kind"`
+	ReceivedAt time.Time `json:"received_at"`
+	DurationMs *int64    `json:"duration_ms"` // Time since the start ping, for the success and fail pings
+	Message    string    `json:"message"`
+}
+
+// LastPings holds the last ping of each kind received by a monitor, nil if none was received.
+type LastPings struct {
+	Start   *Ping
+	Success *Ping
+	Fail    *Ping
+}
+
+// Finished returns the last success or fail ping, nil if the job never finished.
+func (l LastPings) Finished() *Ping {
+	switch {
+	case l.Success == nil:
+		return l.Fail
+	case l.Fail == nil || l.Success.ReceivedAt.After(l.Fail.ReceivedAt):
+		return l.Success
+	default:
+		return l.Fail
+	}
+}
+
+type PingModel struct {
+	DB *sql.DB
+}
+
+func NewPingModel(db *sql.DB) *PingModel {
+	return &PingModel{DB: db}
+}
+
+type PingInterface interface {
+	Insert(ctx context.Context, ping Ping, log zerolog.Logger) (*Ping, error)
+	GetLast(ctx context.Context, monitorID int64, log zerolog.Logger) (*LastPings, error)
+}
+
+// Insert stores the ping. The duration of the success and fail pings is measured from the start
+// ping received after the previous success or fail ping, if any.
+func (m *PingModel) Insert(ctx context.Context, ping Ping, log zerolog.Logger) (*Ping, error) {
+	log.Debug().Msg("Inserting ping")
+	if ping.Kind != PingStart {
+		last, err := m.GetLast(ctx, ping.MonitorID, log)
+		if err != nil {
+			return nil, err
+		}
+		finished := last.Finished()
+		if last.Start != nil && (finished == nil || last.Start.ReceivedAt.After(finished.ReceivedAt)) {
+			duration := ping.ReceivedAt.Sub(last.Start.ReceivedAt).Milliseconds()
+			ping.DurationMs = &duration
+		}
+	}
+	err := m.DB.QueryRowContext(ctx, `
+		INSERT INTO pings (monitor_id, kind, received_at, duration_ms, message)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ping_id`,
+		ping.MonitorID, ping.Kind, ping.ReceivedAt, ping.DurationMs, ping.Message).Scan(&ping.PingID)
+	if err != nil {
+		log.Err(err).Msg("Error inserting ping")
+		return nil, err
+	}
+	return &ping, nil
+}
+
+func (m *PingModel) GetLast(ctx context.Context, monitorID int64, log zerolog.Logger) (*LastPings, error) {
+	log.Debug().Msg("Getting last pings")
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT DISTINCT ON (kind) ping_id, monitor_id, kind, received_at, duration_ms, message
+		FROM pings
+		WHERE monitor_id = $1
+		ORDER BY kind, received_at DESC, ping_id DESC`,
+		monitorID)
+	if err != nil {
+		log.Err(err).Msg("Error getting last pings")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var last LastPings
+	for rows.Next() {
+		var ping Ping
+		err := rows.Scan(&ping.PingID, &ping.MonitorID, &ping.Kind, &ping.ReceivedAt, &ping.DurationMs, &ping.Message)
+		if err != nil {
+			log.Err(err).Msg("Error scanning rows")
+			return nil, err
+		}
+		switch ping.Kind {
+		case PingStart:
+			last.Start = &ping
+		case PingSuccess:
+			last.Success = &ping
+		case PingFail:
+			last.Fail = &ping
+		}
+	}
+	if err = rows.Err(); err != nil {
+		log.Err(err).Msg("Error iterating rows")
+		return nil, err
+	}
+	return &last, nil
+}
@@ -0,0 +1,26 @@
+package data
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+type PingModelMock struct {
+	mock.Mock
+}
+
+func NewPingModelMock() *PingModelMock {
+	return &PingModelMock{}
+}
+
+func (m *PingModelMock) Insert(ctx context.Context, ping Ping, log zerolog.Logger) (*Ping, error) {
+	args := m.Called(ctx, ping, log)
+	return args.Get(0).(*Ping), args.Error(1)
+}
+
+func (m *PingModelMock) GetLast(ctx context.Context, monitorID int64, log zerolog.Logger) (*LastPings, error) {
+	args := m.Called(ctx, monitorID, log)
+	return args.Get(0).(*LastPings), args.Error(1)
+}
@@ -0,0 +1,133 @@
+// The incident package turns the stream of check results of each monitor into incidents. A monitor
+// that has been failing continuously for at least ThresholdMinutes opens an incident, the next
+// failures are attached to it, and the first successful check resolves it.
+package incident
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/rs/zerolog"
+)
+
+type EventType string
+
+const (
+	EventOpened   EventType = "incident.opened"
+	EventResolved EventType = "incident.resolved"
+)
+
+// Event is returned by Observe when an incident changes state.
+type Event struct {
+	Type     EventType
+	Monitor  data.Monitor
+	Incident data.Incident
+}
+
+// streak holds the consecutive failures of a monitor that do not have an incident yet.
+type streak struct {
+	since     time.Time
+	failures  int
+	lastError string
+}
+
+// Tracker keeps the failure streaks in memory, so after a restart a monitor without an open
+// incident needs to fail for ThresholdMinutes again before an incident is opened.
+type Tracker struct {
+	incidents data.IncidentInterface
+
+	mu      sync.Mutex
+	streaks map[int64]*streak
+}
+
+func NewTracker(incidents data.IncidentInterface) *Tracker {
+	return &Tracker{
+		incidents: incidents,
+		streaks:   make(map[int64]*streak),
+	}
+}
+
+// Observe feeds a check result of the monitor to the state machine. It returns the event that
+// happened because of this result, or nil if the state of the monitor did not change.
+func (t *Tracker) Observe(ctx context.Context, monitor data.Monitor, result data.CheckResult, log zerolog.Logger) (*Event, error) {
+	open, err := t.incidents.GetOpen(ctx, monitor.MonitorID, log)
+	if err != nil && !errors.Is(err, data.ErrIncidentNotFound) {
+		return nil, err
+	}
+
+	if result.Success {
+		t.reset(monitor.MonitorID)
+		if open == nil {
+			return nil, nil
+		}
+		resolved, err := t.incidents.Resolve(ctx, open.IncidentID, result.CheckedAt, log)
+		if err != nil {
+			return nil, err
+		}
+		return &Event{Type: EventResolved, Monitor: monitor, Incident: *resolved}, nil
+	}
+
+	if open != nil {
+		t.reset(monitor.MonitorID)
+		return nil, t.incidents.AttachFailure(ctx, open.IncidentID, result.CheckedAt, result.Error, log)
+	}
+
+	current := t.fail(monitor.MonitorID, result)
+	threshold := time.Duration(monitor.ThresholdMinutes) * time.Minute
+	if result.CheckedAt.Sub(current.since) < threshold {
+		return nil, nil
+	}
+	opened, err := t.incidents.Open(ctx, data.Incident{
+		MonitorID:     monitor.MonitorID,
+		FailingSince:  current.since,
+		OpenedAt:      result.CheckedAt,
+		LastFailureAt: result.CheckedAt,
+		FailureCount:  current.failures,
+		LastError:     current.lastError,
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	t.reset(monitor.MonitorID)
+	return &Event{Type: EventOpened, Monitor: monitor, Incident: *opened}, nil
+}
+
+// Stop resolves the open incident of a monitor that is not checked anymore, like a deleted
+// monitor, since no successful check would resolve it. It returns nil if there was no incident.
+func (t *Tracker) Stop(ctx context.Context, monitor data.Monitor, stoppedAt time.Time, log zerolog.Logger) (*Event, error) {
+	t.reset(monitor.MonitorID)
+	open, err := t.incidents.GetOpen(ctx, monitor.MonitorID, log)
+	if errors.Is(err, data.ErrIncidentNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := t.incidents.Resolve(ctx, open.IncidentID, stoppedAt, log)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Type: EventResolved, Monitor: monitor, Incident: *resolved}, nil
+}
+
+func (t *Tracker) fail(monitorID int64, result data.CheckResult) streak {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.streaks[monitorID]
+	if !ok {
+		current = &streak{since: result.CheckedAt}
+		t.streaks[monitorID] = current
+	}
+	current.failures++
+	current.lastError = result.Error
+	return *current
+}
+
+func (t *Tracker) reset(monitorID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.streaks, monitorID)
+}
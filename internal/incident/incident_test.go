@@ -0,0 +1,117 @@
+package incident
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTracker_Observe(t *testing.T) {
+	start := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	monitor := data.Monitor{MonitorID: 1, ThresholdMinutes: 5}
+	failure := func(minutes int) data.CheckResult {
+		return data.CheckResult{MonitorID: 1, CheckedAt: start.Add(time.Duration(minutes) * time.Minute), Error: "timeout"}
+	}
+	success := func(minutes int) data.CheckResult {
+		return data.CheckResult{MonitorID: 1, CheckedAt: start.Add(time.Duration(minutes) * time.Minute), Success: true}
+	}
+	log := zerolog.Nop()
+	ctx := context.Background()
+
+	incidents := data.NewIncidentModelMock()
+	tracker := NewTracker(incidents)
+
+	//failures shorter than the threshold do not open an incident
+	incidents.On("GetOpen", mock.Anything, int64(1), mock.Anything).Return((*data.Incident)(nil), data.ErrIncidentNotFound).Times(3)
+	for _, minute := range []int{0, 2, 4} {
+		event, err := tracker.Observe(ctx, monitor, failure(minute), log)
+		assert.NoError(t, err)
+		assert.Nil(t, event)
+	}
+
+	//failing for 5 minutes opens the incident with all the failures of the streak
+	opened := &data.Incident{IncidentID: 7, MonitorID: 1, Status: data.IncidentStatusOpen}
+	incidents.On("GetOpen", mock.Anything, int64(1), mock.Anything).Return((*data.Incident)(nil), data.ErrIncidentNotFound).Once()
+	incidents.On("Open", mock.Anything, mock.MatchedBy(func(i data.Incident) bool {
+		return i.FailingSince.Equal(start) && i.FailureCount == 4 && i.LastError == "timeout"
+	}), mock.Anything).Return(opened, nil).Once()
+	event, err := tracker.Observe(ctx, monitor, failure(5), log)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, EventOpened, event.Type)
+		assert.Equal(t, int64(7), event.Incident.IncidentID)
+	}
+
+	//further failures are attached to the open incident
+	incidents.On("GetOpen", mock.Anything, int64(1), mock.Anything).Return(opened, nil).Twice()
+	incidents.On("AttachFailure", mock.Anything, int64(7), failure(6).CheckedAt, "timeout", mock.Anything).Return(nil).Once()
+	event, err = tracker.Observe(ctx, monitor, failure(6), log)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+
+	//the first success resolves the incident
+	resolvedAt := success(7).CheckedAt
+	resolved := &data.Incident{IncidentID: 7, MonitorID: 1, Status: data.IncidentStatusResolved, ResolvedAt: &resolvedAt}
+	incidents.On("Resolve", mock.Anything, int64(7), resolvedAt, mock.Anything).Return(resolved, nil).Once()
+	event, err = tracker.Observe(ctx, monitor, success(7), log)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, EventResolved, event.Type)
+	}
+	incidents.AssertExpectations(t)
+}
+
+func TestTracker_ObserveSuccessResetsStreak(t *testing.T) {
+	start := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	monitor := data.Monitor{MonitorID: 1, ThresholdMinutes: 5}
+	log := zerolog.Nop()
+
+	incidents := data.NewIncidentModelMock()
+	incidents.On("GetOpen", mock.Anything, int64(1), mock.Anything).Return((*data.Incident)(nil), data.ErrIncidentNotFound)
+	tracker := NewTracker(incidents)
+
+	results := []data.CheckResult{
+		{CheckedAt: start, Error: "timeout"},
+		{CheckedAt: start.Add(3 * time.Minute), Success: true},
+		{CheckedAt: start.Add(6 * time.Minute), Error: "timeout"},
+		{CheckedAt: start.Add(9 * time.Minute), Error: "timeout"},
+	}
+	for _, result := range results {
+		event, err := tracker.Observe(context.Background(), monitor, result, log)
+		assert.NoError(t, err)
+		assert.Nil(t, event)
+	}
+	incidents.AssertNotCalled(t, "Open", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTracker_Stop(t *testing.T) {
+	stoppedAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	log := zerolog.Nop()
+
+	incidents := data.NewIncidentModelMock()
+	open := &data.Incident{IncidentID: 7, MonitorID: 1, Status: data.IncidentStatusOpen}
+	resolved := &data.Incident{IncidentID: 7, MonitorID: 1, Status: data.IncidentStatusResolved, ResolvedAt: &stoppedAt}
+	incidents.On("GetOpen", mock.Anything, int64(1), mock.Anything).Return(open, nil).Once()
+	incidents.On("Resolve", mock.Anything, int64(7), stoppedAt, mock.Anything).Return(resolved, nil).Once()
+	incidents.On("GetOpen", mock.Anything, int64(2), mock.Anything).Return((*data.Incident)(nil), data.ErrIncidentNotFound).Once()
+	tracker := NewTracker(incidents)
+
+	//the open incident of the deleted monitor is resolved
+	event, err := tracker.Stop(context.Background(), data.Monitor{MonitorID: 1}, stoppedAt, log)
+	assert.NoError(t, err)
+	if assert.NotNil(t, event) {
+		assert.Equal(t, EventResolved, event.Type)
+		assert.Equal(t, int64(7), event.Incident.IncidentID)
+	}
+
+	//without an open incident there is nothing to resolve
+	event, err = tracker.Stop(context.Background(), data.Monitor{MonitorID: 2}, stoppedAt, log)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+	incidents.AssertExpectations(t)
+}
@@ -0,0 +1,316 @@
+// The migrate package applies the SQL migrations of the database, recording the applied versions
+// in the schema_versions table. Each migration runs in its own transaction with its version, so a
+// failed migration leaves the database at the previous version. The migrations that can lose data,
+// like dropping a table or a column, only run when they are explicitly allowed.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// lockID is the key of the advisory lock held while migrating, so the instances starting at the
+// same time do not apply the same migration twice.
+const lockID = 73512023
+
+var (
+	fileName    = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	destructive = regexp.MustCompile(`(?i)\b(DROP\s+(TABLE|COLUMN|SCHEMA|DATABASE)|TRUNCATE|DELETE\s+FROM)\b`)
+	comment     = regexp.MustCompile(`--[^\n]*`)
+)
+
+// Migration is a version of the database schema, with the SQL that upgrades to it and the SQL that
+// reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Destructive reports whether the SQL of the direction can lose data.
+func (m Migration) Destructive(up bool) bool {
+	query := m.Down
+	if up {
+		query = m.Up
+	}
+	return destructive.MatchString(comment.ReplaceAllString(query, ""))
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%06d_%s", m.Version, m.Name)
+}
+
+// DestructiveError is returned when a migration that can lose data is not allowed to run.
+type DestructiveError struct {
+	Migration Migration
+	Direction string
+}
+
+func (e *DestructiveError) Error() string {
+	return fmt.Sprintf("migration %s %s can lose data and must be explicitly allowed", e.Migration, e.Direction)
+}
+
+// Status is a migration with the time it was applied, nil while it is pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load reads the migrations of the directory, sorted by version. Every version must have an up
+// file, the down file is optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		match := fileName.FindStringSubmatch(file)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, must be <version>_<name>.up.sql or <version>_<name>.down.sql", file)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of the migration file %s: %w", file, err)
+		}
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has two names, %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s does not have an up file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     zerolog.Logger
+}
+
+func New(db *sql.DB, fsys fs.FS, logger zerolog.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// Status returns all the migrations, with the time the applied ones were applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var status []Status
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			s := Status{Migration: migration}
+			if at, ok := applied[migration.Version]; ok {
+				s.AppliedAt = &at
+			}
+			status = append(status, s)
+		}
+		return nil
+	})
+	return status, err
+}
+
+// Up applies the pending migrations in order, returning how many were applied. Nothing is applied
+// when one of them is destructive and allowDestructive is false.
+func (m *Migrator) Up(ctx context.Context, allowDestructive bool) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		var pending []Migration
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok {
+				pending = append(pending, migration)
+			}
+		}
+		if !allowDestructive {
+			for _, migration := range pending {
+				if migration.Destructive(true) {
+					return &DestructiveError{Migration: migration, Direction: "up"}
+				}
+			}
+		}
+		for _, migration := range pending {
+			m.logger.Info().Int64("version", migration.Version).Str("name", migration.Name).Msg("Applying migration")
+			err := m.apply(ctx, conn, migration.Up, `INSERT INTO schema_versions (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("applying migration %s: %w", migration, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the last steps applied migrations, the most recent first, returning how many were
+// reverted. Nothing is reverted when one of them is destructive and allowDestructive is false.
+func (m *Migrator) Down(ctx context.Context, steps int, allowDestructive bool) (int, error) {
+	count := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		var reverted []Migration
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				reverted = append(reverted, m.migrations[i])
+			}
+		}
+		for _, migration := range reverted {
+			if migration.Down == "" {
+				return fmt.Errorf("migration %s does not have a down file", migration)
+			}
+			if !allowDestructive && migration.Destructive(false) {
+				return &DestructiveError{Migration: migration, Direction: "down"}
+			}
+		}
+		for _, migration := range reverted {
+			m.logger.Info().Int64("version", migration.Version).Str("name", migration.Name).Msg("Reverting migration")
+			err := m.apply(ctx, conn, migration.Down, `DELETE FROM schema_versions WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("reverting migration %s: %w", migration, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// apply runs the SQL of a migration and the statement that records it in the same transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, query, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// locked runs the function holding the advisory lock, after creating the schema_versions table.
+func (m *Migrator) locked(ctx context.Context, f func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("locking the migrations: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			m.logger.Err(err).Msg("Error unlocking the migrations")
+		}
+	}()
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_versions (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return fmt.Errorf("creating the schema_versions table: %w", err)
+	}
+	if err = m.adoptMigrateCLI(ctx, conn); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+// adoptMigrateCLI records as applied the migrations applied by the migrate CLI, that were used
+// before this package, when the schema_versions table is still empty.
+func (m *Migrator) adoptMigrateCLI(ctx context.Context, conn *sql.Conn) error {
+	var recorded bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM schema_versions) OR to_regclass('schema_migrations') IS NULL`).Scan(&recorded)
+	if err != nil || recorded {
+		return err
+	}
+	var version int64
+	var dirty bool
+	err = conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading the schema_migrations table of the migrate CLI: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("the migrate CLI left the database dirty at version %d, it must be fixed by hand", version)
+	}
+	m.logger.Info().Int64("version", version).Msg("Adopting the migrations applied by the migrate CLI")
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		_, err := conn.ExecContext(ctx, `INSERT INTO schema_versions (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+		if err != nil {
+			return fmt.Errorf("adopting migration %s: %w", migration, err)
+		}
+	}
+	return nil
+}
+
+// appliedVersions returns the time each applied version was applied.
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_versions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/The-Sailors/simplemon/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       fstest.MapFS
+		expected    []Migration
+		expectedErr bool
+	}{
+		{
+			name: "Test Load sorts the migrations by version",
+			files: fstest.MapFS{
+				"000002_add_config.up.sql":     {Data: []byte("ALTER TABLE monitors ADD COLUMN config JSONB;")},
+				"000002_add_config.down.sql":   {Data: []byte("ALTER TABLE monitors DROP COLUMN config;")},
+				"000001_create_table.up.sql":   {Data: []byte("CREATE TABLE monitors ();")},
+				"000001_create_table.down.sql": {Data: []byte("DROP TABLE monitors;")},
+			},
+			expected: []Migration{
+				{Version: 1, Name: "create_table", Up: "CREATE TABLE monitors ();", Down: "DROP TABLE monitors;"},
+				{Version: 2, Name: "add_config", Up: "ALTER TABLE monitors ADD COLUMN config JSONB;", Down: "ALTER TABLE monitors DROP COLUMN config;"},
+			},
+		},
+		{
+			name: "Test Load migration without up file",
+			files: fstest.MapFS{
+				"000001_create_table.down.sql": {Data: []byte("DROP TABLE monitors;")},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Test Load two names for the same version",
+			files: fstest.MapFS{
+				"000001_create_table.up.sql":    {Data: []byte("CREATE TABLE monitors ();")},
+				"000001_create_monitors.up.sql": {Data: []byte("CREATE TABLE monitors ();")},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Test Load invalid file name",
+			files: fstest.MapFS{
+				"create_table.sql": {Data: []byte("CREATE TABLE monitors ();")},
+			},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, migrations)
+		})
+	}
+}
+
+func TestMigration_Destructive(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected bool
+	}{
+		{name: "Test Destructive create table", query: "CREATE TABLE IF NOT EXISTS monitors (monitor_id SERIAL);", expected: false},
+		{name: "Test Destructive drop table", query: "DROP TABLE IF EXISTS monitors;", expected: true},
+		{name: "Test Destructive drop column", query: "ALTER TABLE monitors DROP COLUMN IF EXISTS config;", expected: true},
+		{name: "Test Destructive delete rows", query: "delete from monitors where deleted_at is not null;", expected: true},
+		{name: "Test Destructive truncate", query: "TRUNCATE pings;", expected: true},
+		{name: "Test Destructive drop index and constraint", query: "DROP INDEX IF EXISTS idx;\nALTER TABLE monitors DROP CONSTRAINT IF EXISTS monitor_id;", expected: false},
+		{name: "Test Destructive trigger on delete", query: "CREATE TRIGGER t BEFORE UPDATE OR DELETE ON audit_events FOR EACH ROW EXECUTE FUNCTION f();", expected: false},
+		{name: "Test Destructive only in a comment", query: "-- DROP TABLE monitors would lose the monitors\nCREATE INDEX idx ON monitors (url);", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Migration{Up: tt.query}.Destructive(true))
+		})
+	}
+}
+
+// TestEmbeddedMigrations verifies that the migrations of the repository load and that they can be
+// applied on start, where the destructive migrations are refused.
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, all)
+	for i, migration := range all {
+		assert.Equal(t, int64(i+1), migration.Version, "the versions must follow each other")
+		assert.NotEmpty(t, migration.Down, "migration %s must have a down file", migration)
+		assert.False(t, migration.Destructive(true), "migration %s up can lose data", migration)
+	}
+}
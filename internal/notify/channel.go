@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/validator"
+)
+
+// Kinds of channel.
+const (
+	ChannelEmail       = "email"
+	ChannelWebhook     = "webhook"
+	ChannelChatWebhook = "chat-webhook"
+)
+
+var ChannelKinds = []string{ChannelEmail, ChannelWebhook, ChannelChatWebhook}
+
+// MaxChannelAddresses is the max number of addresses of an email channel.
+const MaxChannelAddresses = 20
+
+// EmailChannelConfig is the config of the email channels.
+type EmailChannelConfig struct {
+	Addresses []string `json:"addresses"`
+}
+
+// WebhookChannelConfig is the config of the webhook channels, see WebhookNotifier.
+type WebhookChannelConfig struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// ChatWebhookChannelConfig is the config of the chat-webhook channels, see ChatWebhookNotifier.
+type ChatWebhookChannelConfig struct {
+	URL    string `json:"url"`
+	Format string `json:"format,omitempty"` // One of ChatFormats, text when empty
+}
+
+var ErrEmailDisabled = errors.New("email notifications are disabled, SMTP_HOST is not set")
+
+// RedactedSecret replaces the secrets of the channels returned by the API. A channel updated with
+// the redacted values keeps its current secrets.
+const RedactedSecret = "********"
+
+// Channels validates the channels and builds the notifier of each one.
+type Channels struct {
+	email   *EmailNotifier // nil when email notifications are disabled
+	baseURL string         // Public URL of simplemon, used by the chat messages to link the monitor
+}
+
+// NewChannels returns the channels, sending the emails with the notifier. The email channels fail
+// with ErrEmailDisabled if it is nil.
+func NewChannels(email *EmailNotifier, baseURL string) *Channels {
+	return &Channels{email: email, baseURL: baseURL}
+}
+
+// Validate verifies the kind and the config of the channel, returning a *data.ValidationError.
+func (c *Channels) Validate(channel data.Channel) error {
+	v := validator.New()
+	switch channel.Kind {
+	case ChannelEmail:
+		var config EmailChannelConfig
+		if err := decodeConfig(channel.Config, &config); err != nil {
+			v.AddError("config", err.Error())
+		}
+		v.Check(len(config.Addresses) > 0, "config", "addresses must have at least one address")
+		v.Check(len(config.Addresses) <= MaxChannelAddresses, "config", fmt.Sprintf("addresses must not have more than %d addresses", MaxChannelAddresses))
+		for i, address := range config.Addresses {
+			v.Check(validator.IsEmail(address), "config", fmt.Sprintf("addresses[%d] must be a valid email address", i))
+		}
+	case ChannelWebhook:
+		var config WebhookChannelConfig
+		if err := decodeConfig(channel.Config, &config); err != nil {
+			v.AddError("config", err.Error())
+		}
+		v.Check(validWebhookURL(config.URL), "config", "url must be an absolute http or https url")
+	case ChannelChatWebhook:
+		var config ChatWebhookChannelConfig
+		if err := decodeConfig(channel.Config, &config); err != nil {
+			v.AddError("config", err.Error())
+		}
+		v.Check(validWebhookURL(config.URL), "config", "url must be an absolute http or https url")
+		v.Check(config.Format == "" || validator.PermittedValue(config.Format, ChatFormats...), "config", "format must be one of "+strings.Join(ChatFormats, ", "))
+	default:
+		v.AddError("kind", "must be one of "+strings.Join(ChannelKinds, ", "))
+	}
+	if !v.Valid() {
+		return &data.ValidationError{Fields: v.Errors}
+	}
+	return nil
+}
+
+// Notifier returns the notifier that sends the events to the channel.
+func (c *Channels) Notifier(channel data.Channel) (Notifier, error) {
+	switch channel.Kind {
+	case ChannelEmail:
+		var config EmailChannelConfig
+		if err := decodeConfig(channel.Config, &config); err != nil {
+			return nil, err
+		}
+		if c.email == nil {
+			return nil, ErrEmailDisabled
+		}
+		return &emailChannel{email: c.email, to: config.Addresses}, nil
+	case ChannelWebhook:
+		var config WebhookChannelConfig
+		if err := decodeConfig(channel.Config, &config); err != nil {
+			return nil, err
+		}
+		return NewWebhookNotifier(WebhookConfig{URL: config.URL, Secret: config.Secret}), nil
+	case ChannelChatWebhook:
+		var config ChatWebhookChannelConfig
+		if err := decodeConfig(channel.Config, &config); err != nil {
+			return nil, err
+		}
+		return NewChatWebhookNotifier(ChatWebhookConfig{URL: config.URL, Format: config.Format, BaseURL: c.baseURL}), nil
+	default:
+		return nil, fmt.Errorf("unknown channel kind %q", channel.Kind)
+	}
+}
+
+// emailChannel sends the incident events to the addresses of an email channel.
+type emailChannel struct {
+	email *EmailNotifier
+	to    []string
+}
+
+func (n *emailChannel) Notify(ctx context.Context, event Event) error {
+	if !event.Type.IsIncident() {
+		return nil
+	}
+	return n.email.Send(ctx, n.to, event)
+}
+
+func validWebhookURL(rawURL string) bool {
+	target, err := url.Parse(rawURL)
+	return err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != ""
+}
+
+// decodeConfig decodes the config of a channel, rejecting the fields that config does not have.
+func decodeConfig(raw json.RawMessage, config interface{}) error {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(config); err != nil {
+		return fmt.Errorf("invalid config: %v", err)
+	}
+	return nil
+}
+
+// Redact returns the channel with the secrets of its config redacted: the secret of the webhooks,
+// and the url when it is a credential, like the chat webhooks with a token in their path or the
+// webhooks with a user or a query string. The redacted url keeps its scheme and host. The config
+// is removed when it can not be decoded, so it can not leak a secret either.
+func Redact(channel data.Channel) data.Channel {
+	if len(channel.Config) == 0 {
+		return channel
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(channel.Config, &config); err != nil {
+		channel.Config = nil
+		return channel
+	}
+	if redactConfig(channel.Kind, config) {
+		channel.Config, _ = json.Marshal(config)
+	}
+	return channel
+}
+
+// redactConfig redacts the secrets of the decoded config, reporting whether it changed.
+func redactConfig(kind string, config map[string]interface{}) bool {
+	changed := false
+	if secret, ok := config["secret"].(string); ok && secret != "" {
+		config["secret"] = RedactedSecret
+		changed = true
+	}
+	if rawURL, ok := config["url"].(string); ok && secretURL(kind, rawURL) {
+		config["url"] = redactURL(rawURL)
+		changed = true
+	}
+	return changed
+}
+
+// secretURL reports whether the url of a channel of the kind is a credential.
+func secretURL(kind, rawURL string) bool {
+	if rawURL == "" {
+		return false
+	}
+	if kind == ChannelChatWebhook {
+		return true
+	}
+	target, err := url.Parse(rawURL)
+	return err != nil || target.User != nil || target.RawQuery != ""
+}
+
+// redactURL keeps the scheme and the host of the url, the rest is replaced by RedactedSecret.
+func redactURL(rawURL string) string {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return RedactedSecret
+	}
+	return target.Scheme + "://" + target.Host + "/" + RedactedSecret
+}
+
+// KeepSecrets returns the channel with the secrets of the current channel where it has their
+// redacted values, so the channels returned by the API can be sent back as they are.
+func KeepSecrets(channel, current data.Channel) data.Channel {
+	var config, currentConfig map[string]interface{}
+	if json.Unmarshal(channel.Config, &config) != nil {
+		return channel
+	}
+	if json.Unmarshal(current.Config, &currentConfig) != nil || currentConfig == nil {
+		currentConfig = map[string]interface{}{}
+	}
+	redacted := make(map[string]interface{}, len(currentConfig))
+	for key, value := range currentConfig {
+		redacted[key] = value
+	}
+	redactConfig(current.Kind, redacted)
+	changed := false
+	for _, key := range []string{"secret", "url"} {
+		value, ok := config[key].(string)
+		if !ok || !strings.Contains(value, RedactedSecret) {
+			continue
+		}
+		currentValue, _ := currentConfig[key].(string)
+		switch {
+		case currentValue != "" && value == redacted[key]:
+			config[key] = currentValue
+		case currentValue == "" && value == RedactedSecret:
+			delete(config, key)
+		default:
+			continue
+		}
+		changed = true
+	}
+	if changed {
+		channel.Config, _ = json.Marshal(config)
+	}
+	return channel
+}
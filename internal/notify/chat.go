@@ -0,0 +1,240 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Formats of the chat-webhook channels, that is the shape of the payload expected by the incoming
+// webhook of the chat.
+const (
+	ChatText    = "text"    // {"text": "..."}, also understood by Slack, Mattermost and Rocket.Chat
+	ChatSlack   = "slack"   // Slack incoming webhook with blocks in a colored attachment
+	ChatTeams   = "teams"   // Microsoft Teams connector MessageCard
+	ChatDiscord = "discord" // Discord webhook with an embed
+)
+
+var ChatFormats = []string{ChatText, ChatSlack, ChatTeams, ChatDiscord}
+
+// Colors of the chat messages by severity of the event.
+const (
+	colorDown = 0xD00000
+	colorUp   = 0x2EB67D
+	colorInfo = 0x439FE0
+)
+
+var chatText = template.Must(template.New("chat").Parse(
+	`{{if .Test}}[test] {{end}}{{if eq .Type "incident.opened"}}DOWN{{else if eq .Type "incident.resolved"}}UP{{else}}{{.Type}}{{end}}: ` +
+		`{{with .Monitor.Method}}{{.}} {{end}}{{.Monitor.URL}}{{with .Monitor.Description}} ({{.}}){{end}}` +
+		`{{with .Incident}}{{if .ResolvedAt}}, failing for {{$.Duration}}{{else}}, {{.FailureCount}} failures, last error: {{.LastError}}{{end}}{{end}}`))
+
+type ChatWebhookConfig struct {
+	URL    string
+	Format string // One of ChatFormats, ChatText when empty
+	// BaseURL is the public URL of simplemon, used to link the messages to the monitor. The link is
+	// left out when empty.
+	BaseURL string
+}
+
+// ChatWebhookNotifier POSTs the events to the incoming webhook of a chat, rendered in the format the
+// chat expects.
+type ChatWebhookNotifier struct {
+	Client *http.Client
+	config ChatWebhookConfig
+}
+
+func NewChatWebhookNotifier(config ChatWebhookConfig) *ChatWebhookNotifier {
+	if config.Format == "" {
+		config.Format = ChatText
+	}
+	return &ChatWebhookNotifier{Client: &http.Client{Timeout: 10 * time.Second}, config: config}
+}
+
+func (n *ChatWebhookNotifier) Notify(ctx context.Context, event Event) error {
+	message, err := newChatMessage(event, n.config.BaseURL)
+	if err != nil {
+		return err
+	}
+	var payload interface{}
+	switch n.config.Format {
+	case ChatText:
+		payload = map[string]string{"text": message.Summary}
+	case ChatSlack:
+		payload = message.slack()
+	case ChatTeams:
+		payload = message.teams()
+	case ChatDiscord:
+		payload = message.discord()
+	default:
+		return fmt.Errorf("unknown chat format %q", n.config.Format)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return retry(ctx, 3, time.Second, func() error {
+		return post(ctx, n.Client, n.config.URL, body, nil)
+	})
+}
+
+// chatMessage is the content of an event shared by all the chat formats.
+type chatMessage struct {
+	Summary   string // Single line with the whole event, used as text and fallback
+	Title     string
+	Color     int
+	Fields    []chatField
+	Link      string // URL of the monitor, empty when unknown
+	Timestamp time.Time
+}
+
+type chatField struct {
+	Name  string
+	Value string
+}
+
+func newChatMessage(event Event, baseURL string) (chatMessage, error) {
+	var summary bytes.Buffer
+	if err := chatText.Execute(&summary, event); err != nil {
+		return chatMessage{}, err
+	}
+	message := chatMessage{
+		Summary:   summary.String(),
+		Color:     colorInfo,
+		Timestamp: event.OccurredAt,
+	}
+	target := event.Monitor.URL
+	if event.Monitor.Method != "" {
+		target = event.Monitor.Method + " " + target
+	}
+	switch event.Type {
+	case EventIncidentOpened:
+		message.Title = "DOWN: " + target
+		message.Color = colorDown
+	case EventIncidentResolved:
+		message.Title = "UP: " + target
+		message.Color = colorUp
+	default:
+		message.Title = string(event.Type) + ": " + target
+	}
+	if event.Test {
+		message.Title = "[test] " + message.Title
+	}
+
+	message.addField("Monitor", event.Monitor.Description)
+	message.addField("Type", event.Monitor.MonitorType)
+	if incident := event.Incident; incident != nil {
+		if incident.ResolvedAt == nil {
+			message.addField("Failures", fmt.Sprint(incident.FailureCount))
+			message.addField("Last error", incident.LastError)
+		}
+		message.addField("Failing for", event.Duration().String())
+	}
+	if baseURL != "" && event.Monitor.MonitorID != 0 {
+		message.Link = fmt.Sprintf("%s/v1/monitors/%d", strings.TrimRight(baseURL, "/"), event.Monitor.MonitorID)
+	}
+	return message, nil
+}
+
+// maxChatFieldLength is the max length of the values of the fields, Discord rejects longer ones.
+const maxChatFieldLength = 1024
+
+// addField adds the field unless the value is empty, since some chats reject empty fields.
+func (m *chatMessage) addField(name, value string) {
+	if value != "" {
+		m.Fields = append(m.Fields, chatField{Name: name, Value: truncateRunes(value, maxChatFieldLength)})
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// slack renders the message as blocks inside an attachment, since the color can only be set on the
+// attachments.
+func (m chatMessage) slack() interface{} {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type     string      `json:"type"`
+		Text     *text       `json:"text,omitempty"`
+		Fields   []text      `json:"fields,omitempty"`
+		Elements interface{} `json:"elements,omitempty"`
+	}
+	//the header blocks can not be longer than 150 characters
+	blocks := []block{{Type: "header", Text: &text{Type: "plain_text", Text: truncateRunes(m.Title, 150)}}}
+	if len(m.Fields) > 0 {
+		fields := block{Type: "section"}
+		for _, field := range m.Fields {
+			fields.Fields = append(fields.Fields, text{Type: "mrkdwn", Text: "*" + field.Name + "*\n" + field.Value})
+		}
+		blocks = append(blocks, fields)
+	}
+	if m.Link != "" {
+		blocks = append(blocks, block{Type: "actions", Elements: []interface{}{map[string]interface{}{
+			"type": "button",
+			"text": text{Type: "plain_text", Text: "View monitor"},
+			"url":  m.Link,
+		}}})
+	}
+	return map[string]interface{}{
+		"text": m.Summary,
+		"attachments": []interface{}{map[string]interface{}{
+			"color":  fmt.Sprintf("#%06X", m.Color),
+			"blocks": blocks,
+		}},
+	}
+}
+
+// teams renders the message as a connector MessageCard.
+func (m chatMessage) teams() interface{} {
+	facts := []map[string]string{}
+	for _, field := range m.Fields {
+		facts = append(facts, map[string]string{"name": field.Name, "value": field.Value})
+	}
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": fmt.Sprintf("%06X", m.Color),
+		"summary":    m.Summary,
+		"title":      m.Title,
+		"sections":   []interface{}{map[string]interface{}{"facts": facts}},
+	}
+	if m.Link != "" {
+		card["potentialAction"] = []interface{}{map[string]interface{}{
+			"@type":   "OpenUri",
+			"name":    "View monitor",
+			"targets": []map[string]string{{"os": "default", "uri": m.Link}},
+		}}
+	}
+	return card
+}
+
+// discord renders the message as an embed, whose title links to the monitor.
+func (m chatMessage) discord() interface{} {
+	fields := []map[string]interface{}{}
+	for _, field := range m.Fields {
+		fields = append(fields, map[string]interface{}{"name": field.Name, "value": field.Value, "inline": true})
+	}
+	embed := map[string]interface{}{
+		"title":     truncateRunes(m.Title, 256),
+		"color":     m.Color,
+		"fields":    fields,
+		"timestamp": m.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if m.Link != "" {
+		embed["url"] = m.Link
+	}
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
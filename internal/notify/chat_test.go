@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chatPayload decodes the body received by the stand-in of the chat.
+func chatPayload(t *testing.T, format string, event Event) map[string]interface{} {
+	rcv := &webhookReceiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	n := NewChatWebhookNotifier(ChatWebhookConfig{URL: srv.URL, Format: format, BaseURL: "https://simplemon.example.com/"})
+
+	if err := n.Notify(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	if len(rcv.bodies) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(rcv.bodies))
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(rcv.bodies[0], &payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestChatWebhookNotifier_NotifyText(t *testing.T) {
+	payload := chatPayload(t, "", testEvent(EventIncidentOpened))
+	assert.Equal(t, map[string]interface{}{
+		"text": "DOWN: GET https://www.google.com, 4 failures, last error: unexpected status code 503",
+	}, payload)
+}
+
+func TestChatWebhookNotifier_NotifySlack(t *testing.T) {
+	payload := chatPayload(t, ChatSlack, testEvent(EventIncidentOpened))
+
+	assert.Contains(t, payload["text"], "DOWN: GET https://www.google.com")
+	attachment := payload["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "#D00000", attachment["color"])
+	blocks := attachment["blocks"].([]interface{})
+	if assert.Len(t, blocks, 3) {
+		header := blocks[0].(map[string]interface{})
+		assert.Equal(t, "header", header["type"])
+		assert.Equal(t, "DOWN: GET https://www.google.com", header["text"].(map[string]interface{})["text"])
+		fields := blocks[1].(map[string]interface{})["fields"].([]interface{})
+		assert.Contains(t, fields, map[string]interface{}{"type": "mrkdwn", "text": "*Last error*\nunexpected status code 503"})
+		button := blocks[2].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "https://simplemon.example.com/v1/monitors/1", button["url"])
+	}
+}
+
+func TestChatWebhookNotifier_NotifyTeams(t *testing.T) {
+	event := testEvent(EventIncidentResolved)
+	resolvedAt := event.OccurredAt
+	event.Incident.ResolvedAt = &resolvedAt
+	payload := chatPayload(t, ChatTeams, event)
+
+	assert.Equal(t, "MessageCard", payload["@type"])
+	assert.Equal(t, "2EB67D", payload["themeColor"])
+	assert.Equal(t, "UP: GET https://www.google.com", payload["title"])
+	facts := payload["sections"].([]interface{})[0].(map[string]interface{})["facts"].([]interface{})
+	assert.Contains(t, facts, map[string]interface{}{"name": "Failing for", "value": "10m0s"})
+	assert.NotContains(t, facts, map[string]interface{}{"name": "Last error", "value": "unexpected status code 503"})
+	action := payload["potentialAction"].([]interface{})[0].(map[string]interface{})
+	target := action["targets"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "https://simplemon.example.com/v1/monitors/1", target["uri"])
+}
+
+func TestChatWebhookNotifier_NotifyDiscord(t *testing.T) {
+	payload := chatPayload(t, ChatDiscord, testEvent(EventIncidentOpened))
+
+	embed := payload["embeds"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "DOWN: GET https://www.google.com", embed["title"])
+	assert.Equal(t, float64(0xD00000), embed["color"])
+	assert.Equal(t, "https://simplemon.example.com/v1/monitors/1", embed["url"])
+	assert.Equal(t, testEvent(EventIncidentOpened).OccurredAt.Format(time.RFC3339), embed["timestamp"])
+	assert.Contains(t, embed["fields"], map[string]interface{}{"name": "Failures", "value": "4", "inline": true})
+}
+
+func TestChatWebhookNotifier_NotifyTestEvent(t *testing.T) {
+	payload := chatPayload(t, ChatDiscord, NewTestEvent())
+
+	embed := payload["embeds"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "[test] DOWN: GET https://example.com/health", embed["title"])
+	assert.NotContains(t, embed, "url", "made up monitors must not be linked")
+}
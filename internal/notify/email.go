@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	StartTLS bool // Upgrade the connection with STARTTLS, failing if the server does not support it
+}
+
+var ErrStartTLSNotSupported = errors.New("smtp server does not support STARTTLS")
+
+var emailSubject = template.Must(template.New("subject").Parse(
+	`[simplemon] {{if .Test}}TEST {{end}}{{if eq .Type "incident.opened"}}DOWN{{else}}UP{{end}}: {{with .Monitor.Method}}{{.}} {{end}}{{.Monitor.URL}}`))
+
+var emailBody = template.Must(template.New("body").Parse(`{{if .Test -}}
+This is a test notification, the monitor and the incident below are made up.
+
+{{end -}}
+{{if eq .Type "incident.opened" -}}
+Your monitor is failing and an incident was opened.
+{{- else -}}
+Your monitor is healthy again and the incident was resolved.
+{{- end}}
+
+Monitor:    {{.Monitor.MonitorID}} {{.Monitor.Description}}
+Type:       {{.Monitor.MonitorType}}
+URL:        {{.Monitor.URL}}
+{{- with .Monitor.Method}}
+Method:     {{.}}
+{{- end}}
+{{- with .Incident}}
+Incident:   {{.IncidentID}}
+Failures:   {{.FailureCount}}
+Last error: {{.LastError}}
+{{- end}}
+Duration:   {{.Duration}}
+`))
+
+// EmailNotifier sends the incident events by email to the UserEmail of the monitor, the other
+// events are ignored.
+type EmailNotifier struct {
+	config SMTPConfig
+}
+
+func NewEmailNotifier(config SMTPConfig) *EmailNotifier {
+	return &EmailNotifier{config: config}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	if !event.Type.IsIncident() {
+		return nil
+	}
+	return n.Send(ctx, []string{event.Monitor.UserEmail}, event)
+}
+
+// Send renders the event and sends it to the given recipients.
+func (n *EmailNotifier) Send(ctx context.Context, to []string, event Event) error {
+	msg, err := n.render(to, event)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.config.Host, n.config.Port))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if n.config.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return ErrStartTLSNotSupported
+		}
+		if err = c.StartTLS(&tls.Config{ServerName: n.config.Host}); err != nil {
+			return err
+		}
+	}
+	if n.config.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(n.config.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err = c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (n *EmailNotifier) render(to []string, event Event) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := emailSubject.Execute(&subject, event); err != nil {
+		return nil, err
+	}
+	if err := emailBody.Execute(&body, event); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject.String()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
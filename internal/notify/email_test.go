@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn is a minimal SMTP server that records the messages it receives.
+type smtpStandIn struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpts    []string
+	messages []string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpStandIn) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost simplemon stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.rcpts = append(s.rcpts, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var msg strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil || dataLine == ".\r\n" {
+					break
+				}
+				msg.WriteString(dataLine)
+			}
+			s.messages = append(s.messages, msg.String())
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func testEvent(eventType EventType) Event {
+	failingSince := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	event := Event{
+		Type:       eventType,
+		OccurredAt: failingSince.Add(10 * time.Minute),
+		Monitor: data.Monitor{
+			MonitorID: 1,
+			UserEmail: "jojo@gmail.com",
+			URL:       "https://www.google.com",
+			Method:    "GET",
+		},
+		Incident: &data.Incident{IncidentID: 3, FailingSince: failingSince, FailureCount: 4, LastError: "unexpected status code 503"},
+	}
+	return event
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	srv := newSMTPStandIn(t)
+	n := NewEmailNotifier(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     srv.port(),
+		Username: "simplemon",
+		Password: "secret",
+		From:     "alerts@simplemon.dev",
+	})
+
+	err := n.Notify(context.Background(), testEvent(EventIncidentOpened))
+	assert.NoError(t, err)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.NotEmpty(t, srv.auth)
+	assert.Equal(t, "MAIL FROM:<alerts@simplemon.dev>", strings.SplitN(srv.from, " BODY", 2)[0])
+	assert.Equal(t, []string{"RCPT TO:<jojo@gmail.com>"}, srv.rcpts)
+	if assert.Len(t, srv.messages, 1) {
+		msg := srv.messages[0]
+		assert.Contains(t, msg, "Subject: [simplemon] DOWN: GET https://www.google.com")
+		assert.Contains(t, msg, "To: jojo@gmail.com")
+		assert.Contains(t, msg, "Last error: unexpected status code 503")
+		assert.Contains(t, msg, "Duration:   10m0s")
+	}
+}
+
+func TestEmailNotifier_NotifyResolved(t *testing.T) {
+	srv := newSMTPStandIn(t)
+	n := NewEmailNotifier(SMTPConfig{Host: "127.0.0.1", Port: srv.port(), From: "alerts@simplemon.dev"})
+
+	err := n.Notify(context.Background(), testEvent(EventIncidentResolved))
+	assert.NoError(t, err)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Empty(t, srv.auth, "auth must not be used without username")
+	if assert.Len(t, srv.messages, 1) {
+		assert.Contains(t, srv.messages[0], "Subject: [simplemon] UP: GET https://www.google.com")
+		assert.Contains(t, srv.messages[0], "the incident was resolved")
+	}
+}
+
+func TestEmailNotifier_NotifyStartTLSNotSupported(t *testing.T) {
+	srv := newSMTPStandIn(t)
+	n := NewEmailNotifier(SMTPConfig{Host: "127.0.0.1", Port: srv.port(), From: "alerts@simplemon.dev", StartTLS: true})
+
+	err := n.Notify(context.Background(), testEvent(EventIncidentOpened))
+	assert.ErrorIs(t, err, ErrStartTLSNotSupported)
+}
+
+func TestEmailNotifier_NotifyIgnoresMonitorEvents(t *testing.T) {
+	n := NewEmailNotifier(SMTPConfig{Host: "127.0.0.1", Port: "1"})
+
+	err := n.Notify(context.Background(), NewMonitorEvent(EventMonitorCreated, testEvent(EventIncidentOpened).Monitor))
+	assert.NoError(t, err)
+}
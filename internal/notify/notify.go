@@ -0,0 +1,109 @@
+// The notify package delivers the events of simplemon (like an incident being opened or resolved)
+// to the people responsible for the monitors.
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/The-Sailors/simplemon/internal/incident"
+)
+
+type EventType string
+
+const (
+	EventIncidentOpened   EventType = EventType(incident.EventOpened)
+	EventIncidentResolved EventType = EventType(incident.EventResolved)
+	EventMonitorCreated   EventType = "monitor.created"
+	EventMonitorDeleted   EventType = "monitor.deleted"
+	EventMonitorRestored  EventType = "monitor.restored"
+)
+
+// IsIncident reports whether the event is about an incident, in which case Event.Incident is set.
+func (t EventType) IsIncident() bool {
+	return t == EventIncidentOpened || t == EventIncidentResolved
+}
+
+type Event struct {
+	Type       EventType
+	OccurredAt time.Time
+	Monitor    data.Monitor
+	Incident   *data.Incident
+	Test       bool // Sample event sent to test a channel, the monitor and the incident are made up
+}
+
+// NewIncidentEvent converts an event of the incident state machine to a notification event.
+func NewIncidentEvent(event incident.Event) Event {
+	occurredAt := event.Incident.OpenedAt
+	if event.Incident.ResolvedAt != nil {
+		occurredAt = *event.Incident.ResolvedAt
+	}
+	return Event{
+		Type:       EventType(event.Type),
+		OccurredAt: occurredAt,
+		Monitor:    event.Monitor,
+		Incident:   &event.Incident,
+	}
+}
+
+// NewMonitorEvent returns an event about a change of the monitor, like EventMonitorCreated.
+func NewMonitorEvent(eventType EventType, monitor data.Monitor) Event {
+	return Event{
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Monitor:    monitor,
+	}
+}
+
+// NewTestEvent returns a sample incident.opened event, used to verify that a channel works.
+func NewTestEvent() Event {
+	now := time.Now().UTC().Truncate(time.Second)
+	return Event{
+		Type:       EventIncidentOpened,
+		OccurredAt: now,
+		Monitor: data.Monitor{
+			MonitorType:      "http",
+			URL:              "https://example.com/health",
+			Method:           "GET",
+			Description:      "Test notification sent by simplemon",
+			FrequencyMinutes: 1,
+			ThresholdMinutes: 5,
+		},
+		Incident: &data.Incident{
+			Status:        data.IncidentStatusOpen,
+			FailingSince:  now.Add(-5 * time.Minute),
+			OpenedAt:      now,
+			LastFailureAt: now,
+			FailureCount:  5,
+			LastError:     "unexpected status code 503",
+		},
+		Test: true,
+	}
+}
+
+// Duration returns for how long the monitor has been failing, up to the time of the event.
+func (e Event) Duration() time.Duration {
+	if e.Incident == nil {
+		return 0
+	}
+	return e.OccurredAt.Sub(e.Incident.FailingSince).Round(time.Second)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi sends each event with all the notifiers, even when some of them fail.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
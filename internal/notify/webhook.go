@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+)
+
+// WebhookVersion is the version of the JSON payload sent by the webhooks. It changes only when a
+// field is removed or its meaning changes, new fields can be added to the same version.
+const WebhookVersion = 1
+
+// Headers sent with each webhook request.
+const (
+	HeaderEvent     = "X-Simplemon-Event"
+	HeaderDelivery  = "X-Simplemon-Delivery"
+	HeaderTimestamp = "X-Simplemon-Timestamp"
+	HeaderSignature = "X-Simplemon-Signature"
+)
+
+type WebhookConfig struct {
+	URL    string
+	Secret string // Key of the HMAC-SHA256 signature, the requests are not signed when empty
+	// MaxAttempts is the number of requests sent before giving up, 3 when zero.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled after each attempt. 1s when zero.
+	Backoff time.Duration
+}
+
+// WebhookPayload is the JSON body of the webhook requests.
+type WebhookPayload struct {
+	Version    int            `json:"version"`
+	ID         string         `json:"id"` // Same for all the attempts of a delivery, to discard duplicates
+	Type       EventType      `json:"type"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Monitor    WebhookMonitor `json:"monitor"`
+	Incident   *data.Incident `json:"incident,omitempty"`
+	Duration   *float64       `json:"duration_seconds,omitempty"` // Set with the incident
+	Test       bool           `json:"test,omitempty"`             // Sample event sent to test a channel
+}
+
+// WebhookMonitor is the monitor sent in the webhooks. The headers, parameters and body are left
+// out because they may contain credentials.
+type WebhookMonitor struct {
+	MonitorID   int64  `json:"monitor_id"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Method      string `json:"method,omitempty"`
+	Description string `json:"description"`
+	UserEmail   string `json:"user_email"`
+}
+
+// WebhookError is returned when the receiver answers with a status code other than 2xx.
+type WebhookError struct {
+	StatusCode int
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("webhook responded with status code %d", e.StatusCode)
+}
+
+// retryable reports whether the request may succeed if sent again: server errors and rate limits
+// are retried, the other client errors are not.
+func (e *WebhookError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout
+}
+
+// WebhookNotifier POSTs the events as JSON to a URL. Each request carries the unix timestamp in the
+// X-Simplemon-Timestamp header and, when a secret is configured, the signature
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)) in the X-Simplemon-Signature header.
+type WebhookNotifier struct {
+	Client *http.Client
+	config WebhookConfig
+}
+
+func NewWebhookNotifier(config WebhookConfig) *WebhookNotifier {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = time.Second
+	}
+	return &WebhookNotifier{Client: &http.Client{Timeout: 10 * time.Second}, config: config}
+}
+
+// NewWebhookPayload converts the event to the body of the webhooks.
+func NewWebhookPayload(event Event) (WebhookPayload, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return WebhookPayload{}, err
+	}
+	payload := WebhookPayload{
+		Version:    WebhookVersion,
+		ID:         hex.EncodeToString(id),
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Monitor: WebhookMonitor{
+			MonitorID:   event.Monitor.MonitorID,
+			Type:        event.Monitor.MonitorType,
+			URL:         event.Monitor.URL,
+			Method:      event.Monitor.Method,
+			Description: event.Monitor.Description,
+			UserEmail:   event.Monitor.UserEmail,
+		},
+		Incident: event.Incident,
+		Test:     event.Test,
+	}
+	if event.Incident != nil {
+		duration := event.Duration().Seconds()
+		payload.Duration = &duration
+	}
+	return payload, nil
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	payload, err := NewWebhookPayload(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return retry(ctx, n.config.MaxAttempts, n.config.Backoff, func() error {
+		return n.send(ctx, payload, body)
+	})
+}
+
+// retry calls send until it succeeds, waiting backoff before the first retry and doubling it after
+// each attempt. It gives up after maxAttempts, when the context is done or when the error is a
+// *WebhookError that is not retryable.
+func retry(ctx context.Context, maxAttempts int, backoff time.Duration, send func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil {
+			return nil
+		}
+		var webhookErr *WebhookError
+		if errors.As(err, &webhookErr) && !webhookErr.retryable() {
+			return err
+		}
+		if attempt >= maxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, last error: %v", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the JSON body to the URL, returning a *WebhookError if the status code is not 2xx.
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &WebhookError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// send makes a single attempt to deliver the payload, signed with the current time.
+func (n *WebhookNotifier) send(ctx context.Context, payload WebhookPayload, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	headers := http.Header{}
+	headers.Set("User-Agent", "simplemon-webhook/"+strconv.Itoa(WebhookVersion))
+	headers.Set(HeaderEvent, string(payload.Type))
+	headers.Set(HeaderDelivery, payload.ID)
+	headers.Set(HeaderTimestamp, timestamp)
+	if n.config.Secret != "" {
+		headers.Set(HeaderSignature, Sign(n.config.Secret, timestamp, body))
+	}
+	return post(ctx, n.Client, n.config.URL, body, headers)
+}
+
+// Sign returns the value of the X-Simplemon-Signature header of a webhook. Receivers compute it
+// from the raw body and the X-Simplemon-Timestamp header, compare it in constant time, and should
+// reject old timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver answers each request with the next status of the list, 200 when it is over.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	rcv := &webhookReceiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	n := NewWebhookNotifier(WebhookConfig{URL: srv.URL, Secret: "s3cret"})
+
+	err := n.Notify(context.Background(), testEvent(EventIncidentOpened))
+	assert.NoError(t, err)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	if !assert.Len(t, rcv.requests, 1) {
+		return
+	}
+	req, body := rcv.requests[0], rcv.bodies[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "incident.opened", req.Header.Get(HeaderEvent))
+	assert.Equal(t, Sign("s3cret", req.Header.Get(HeaderTimestamp), body), req.Header.Get(HeaderSignature))
+
+	var payload WebhookPayload
+	if assert.NoError(t, json.Unmarshal(body, &payload)) {
+		assert.Equal(t, WebhookVersion, payload.Version)
+		assert.Equal(t, req.Header.Get(HeaderDelivery), payload.ID)
+		assert.Equal(t, EventIncidentOpened, payload.Type)
+		assert.Equal(t, int64(1), payload.Monitor.MonitorID)
+		assert.Equal(t, "https://www.google.com", payload.Monitor.URL)
+		if assert.NotNil(t, payload.Incident) && assert.NotNil(t, payload.Duration) {
+			assert.Equal(t, int64(3), payload.Incident.IncidentID)
+			assert.Equal(t, 600.0, *payload.Duration)
+		}
+	}
+}
+
+func TestWebhookNotifier_NotifyMonitorEvent(t *testing.T) {
+	rcv := &webhookReceiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	n := NewWebhookNotifier(WebhookConfig{URL: srv.URL})
+
+	err := n.Notify(context.Background(), NewMonitorEvent(EventMonitorDeleted, testEvent(EventIncidentOpened).Monitor))
+	assert.NoError(t, err)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	if assert.Len(t, rcv.requests, 1) {
+		assert.Empty(t, rcv.requests[0].Header.Get(HeaderSignature), "requests must not be signed without secret")
+		assert.NotContains(t, string(rcv.bodies[0]), `"incident"`)
+		assert.Contains(t, string(rcv.bodies[0]), `"type":"monitor.deleted"`)
+	}
+}
+
+func TestWebhookNotifier_NotifyRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		wantErr  bool
+	}{
+		{name: "Test Notify retries server errors", statuses: []int{503, 500}, attempts: 3},
+		{name: "Test Notify retries rate limits", statuses: []int{429}, attempts: 2},
+		{name: "Test Notify gives up after max attempts", statuses: []int{502, 502, 502, 502}, attempts: 3, wantErr: true},
+		{name: "Test Notify does not retry client errors", statuses: []int{404}, attempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv := &webhookReceiver{statuses: tt.statuses}
+			srv := httptest.NewServer(rcv)
+			defer srv.Close()
+			n := NewWebhookNotifier(WebhookConfig{URL: srv.URL, Backoff: time.Millisecond})
+
+			err := n.Notify(context.Background(), testEvent(EventIncidentResolved))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			rcv.mu.Lock()
+			defer rcv.mu.Unlock()
+			if assert.Len(t, rcv.requests, tt.attempts) {
+				id := rcv.requests[0].Header.Get(HeaderDelivery)
+				for _, req := range rcv.requests {
+					assert.Equal(t, id, req.Header.Get(HeaderDelivery), "all the attempts must have the same delivery id")
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier_NotifyCanceled(t *testing.T) {
+	rcv := &webhookReceiver{statuses: []int{500}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	n := NewWebhookNotifier(WebhookConfig{URL: srv.URL, Backoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := n.Notify(ctx, testEvent(EventIncidentOpened))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
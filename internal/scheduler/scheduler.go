@@ -0,0 +1,195 @@
+// The scheduler package is responsible for running the monitors stored in the database. Each
+// monitor runs in its own goroutine on its FrequencyMinutes interval (plus some jitter, so the
+// checks are spread over time), and the list of monitors is periodically reloaded from the
+// database so creates, updates and deletes are picked up without restarting the application.
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/rs/zerolog"
+)
+
+// Runner is the function called every time a monitor must be checked.
+type Runner func(ctx context.Context, monitor data.Monitor)
+
+// IntervalFunc returns how often the monitor must be checked, or 0 to use its FrequencyMinutes.
+type IntervalFunc func(monitor data.Monitor) time.Duration
+
+// maxJitter is the fraction of the interval that can be randomly added to each run.
+const maxJitter = 0.1
+
+type job struct {
+	monitor data.Monitor
+	cancel  context.CancelFunc
+	done    chan struct{} // closed when the goroutine of the job returns
+}
+
+type Scheduler struct {
+	models data.MonitorInterface
+	run    Runner
+	logger zerolog.Logger
+	resync time.Duration
+	unit   time.Duration // unit of the FrequencyMinutes field, changed only by tests
+
+	interval IntervalFunc
+
+	mu   sync.Mutex
+	jobs map[int64]*job
+	wg   sync.WaitGroup
+}
+
+func New(models data.MonitorInterface, run Runner, logger zerolog.Logger, resync time.Duration) *Scheduler {
+	return &Scheduler{
+		models: models,
+		run:    run,
+		logger: logger,
+		resync: resync,
+		unit:   time.Minute,
+		jobs:   make(map[int64]*job),
+	}
+}
+
+// SetInterval overrides the FrequencyMinutes interval of the monitors for which the function
+// returns a positive duration. It must be called before Start.
+func (s *Scheduler) SetInterval(interval IntervalFunc) {
+	s.interval = interval
+}
+
+// Start loads the monitors and keeps them in sync with the database until the context is done.
+// It blocks, so it is meant to be called in its own goroutine.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.logger.Info().Msgf("Starting scheduler, resync every %s", s.resync)
+	ticker := time.NewTicker(s.resync)
+	defer ticker.Stop()
+
+	for {
+		if err := s.reload(ctx); err != nil {
+			s.logger.Err(err).Msg("Error syncing the monitors")
+		}
+		select {
+		case <-ctx.Done():
+			s.stopAll()
+			s.wg.Wait()
+			s.logger.Info().Msg("Scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reload reloads the monitors from the database, starting the new ones, restarting the changed ones
+// and stopping the ones that do not exist anymore.
+func (s *Scheduler) reload(ctx context.Context) error {
+	monitors, err := s.models.GetAll(ctx, s.logger)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int64]bool, len(monitors))
+	for _, monitor := range monitors {
+		seen[monitor.MonitorID] = true
+		current, ok := s.jobs[monitor.MonitorID]
+		if ok && reflect.DeepEqual(current.monitor, monitor) {
+			continue
+		}
+		if ok {
+			s.logger.Info().Int64("monitor_id", monitor.MonitorID).Msg("Monitor changed, rescheduling")
+			current.cancel()
+			delete(s.jobs, monitor.MonitorID)
+		}
+		if monitor.FrequencyMinutes <= 0 {
+			s.logger.Warn().Int64("monitor_id", monitor.MonitorID).Msg("Monitor without frequency, skipping")
+			continue
+		}
+		s.schedule(ctx, monitor)
+	}
+
+	for id, current := range s.jobs {
+		if !seen[id] {
+			s.logger.Info().Int64("monitor_id", id).Msg("Monitor removed, unscheduling")
+			current.cancel()
+			delete(s.jobs, id)
+		}
+	}
+	return nil
+}
+
+// Remove stops checking the monitor right away, instead of at the next reload, and waits for its
+// running check to return. The monitor is scheduled again by the next reload if it still exists.
+func (s *Scheduler) Remove(monitorID int64) {
+	s.mu.Lock()
+	current, ok := s.jobs[monitorID]
+	if ok {
+		current.cancel()
+		delete(s.jobs, monitorID)
+	}
+	s.mu.Unlock()
+	if ok {
+		<-current.done
+	}
+}
+
+// Len returns the number of monitors currently scheduled.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// schedule must be called with the mutex locked.
+func (s *Scheduler) schedule(ctx context.Context, monitor data.Monitor) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.jobs[monitor.MonitorID] = &job{monitor: monitor, cancel: cancel, done: done}
+	interval := time.Duration(monitor.FrequencyMinutes) * s.unit
+	if s.interval != nil {
+		if override := s.interval(monitor); override > 0 {
+			interval = override
+		}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(done)
+		// The first run is delayed by a random fraction of the interval, so monitors loaded at
+		// the same time do not all run at the same instant.
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(interval))))
+		defer timer.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-timer.C:
+			}
+			s.run(jobCtx, monitor)
+			timer.Reset(interval + jitter(interval))
+		}
+	}()
+}
+
+func (s *Scheduler) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, current := range s.jobs {
+		current.cancel()
+		delete(s.jobs, id)
+	}
+}
+
+func jitter(interval time.Duration) time.Duration {
+	max := int64(float64(interval) * maxJitter)
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(max))
+}
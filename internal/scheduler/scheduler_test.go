@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/The-Sailors/simplemon/internal/data"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type runs struct {
+	mu    sync.Mutex
+	count map[int64]int
+}
+
+func (r *runs) run(ctx context.Context, monitor data.Monitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count[monitor.MonitorID]++
+}
+
+func (r *runs) get(id int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count[id]
+}
+
+func newTestScheduler(models data.MonitorInterface, r *runs) *Scheduler {
+	s := New(models, r.run, zerolog.Nop(), time.Hour)
+	s.unit = 10 * time.Millisecond
+	return s
+}
+
+func TestScheduler_reload(t *testing.T) {
+	models := data.NewMonitorModelMock()
+	models.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor{
+		{MonitorID: 1, FrequencyMinutes: 1},
+		{MonitorID: 2, FrequencyMinutes: 2},
+		{MonitorID: 3, FrequencyMinutes: 0},
+	}, nil).Once()
+	models.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor{
+		{MonitorID: 2, FrequencyMinutes: 2},
+	}, nil).Once()
+
+	r := &runs{count: map[int64]int{}}
+	s := newTestScheduler(models, r)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := s.reload(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s.Len(), "monitors without frequency must not be scheduled")
+
+	assert.Eventually(t, func() bool { return r.get(1) >= 2 && r.get(2) >= 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, r.get(3))
+
+	//monitor 1 was deleted
+	err = s.reload(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Len())
+	before := r.get(1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, before, r.get(1), "deleted monitor must not run anymore")
+}
+
+func TestScheduler_reloadError(t *testing.T) {
+	models := data.NewMonitorModelMock()
+	models.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor(nil), errors.New("database generic error"))
+
+	s := newTestScheduler(models, &runs{count: map[int64]int{}})
+	err := s.reload(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestScheduler_Remove(t *testing.T) {
+	models := data.NewMonitorModelMock()
+	models.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor{
+		{MonitorID: 1, FrequencyMinutes: 1},
+		{MonitorID: 2, FrequencyMinutes: 1},
+	}, nil)
+
+	r := &runs{count: map[int64]int{}}
+	s := newTestScheduler(models, r)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := s.reload(ctx)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return r.get(1) >= 1 }, time.Second, 5*time.Millisecond)
+
+	s.Remove(1)
+	s.Remove(3) //not scheduled, nothing to do
+	assert.Equal(t, 1, s.Len())
+	before := r.get(1)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, before, r.get(1), "removed monitor must not run anymore")
+	assert.Greater(t, r.get(2), 1)
+}
+
+func TestScheduler_Start(t *testing.T) {
+	models := data.NewMonitorModelMock()
+	models.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor{{MonitorID: 1, FrequencyMinutes: 1}}, nil)
+
+	r := &runs{count: map[int64]int{}}
+	s := newTestScheduler(models, r)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return r.get(1) >= 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+	assert.Equal(t, 0, s.Len())
+}
+
+func TestScheduler_SetInterval(t *testing.T) {
+	models := data.NewMonitorModelMock()
+	models.On("GetAll", mock.Anything, mock.Anything).Return([]data.Monitor{
+		{MonitorID: 1, MonitorType: "heartbeat", FrequencyMinutes: 1000},
+		{MonitorID: 2, MonitorType: "http", FrequencyMinutes: 1000},
+	}, nil)
+
+	r := &runs{count: map[int64]int{}}
+	s := newTestScheduler(models, r)
+	s.SetInterval(func(monitor data.Monitor) time.Duration {
+		if monitor.MonitorType == "heartbeat" {
+			return 10 * time.Millisecond
+		}
+		return 0
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := s.reload(ctx)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return r.get(1) >= 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, r.get(2), "monitors without override must use their frequency")
+}
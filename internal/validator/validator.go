@@ -0,0 +1,69 @@
+// The validator package collects the problems of each field of a request, so all of them can be
+// reported to the client at once instead of failing on the first one.
+package validator
+
+import (
+	"net/mail"
+	"regexp"
+)
+
+var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
+// Validator holds the problem of each invalid field, keyed by the JSON name of the field.
+type Validator struct {
+	Errors map[string]string
+}
+
+func New() *Validator {
+	return &Validator{Errors: make(map[string]string)}
+}
+
+// Valid returns true if no error was added.
+func (v *Validator) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// AddError adds the error of the field, keeping the first one if the field already has an error.
+func (v *Validator) AddError(key, message string) {
+	if _, exists := v.Errors[key]; !exists {
+		v.Errors[key] = message
+	}
+}
+
+// Check adds the error of the field only if the validation is not ok.
+func (v *Validator) Check(ok bool, key, message string) {
+	if !ok {
+		v.AddError(key, message)
+	}
+}
+
+// Merge adds the errors of another validation, keeping the errors already added.
+func (v *Validator) Merge(errors map[string]string) {
+	for key, message := range errors {
+		v.AddError(key, message)
+	}
+}
+
+// PermittedValue returns true if the value is one of the permitted values.
+func PermittedValue[T comparable](value T, permittedValues ...T) bool {
+	for i := range permittedValues {
+		if value == permittedValues[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the value matches the regular expression.
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
+
+// IsEmail returns true if the value is a plain email address, like jojo@gmail.com.
+func IsEmail(value string) bool {
+	if len(value) > 254 || !Matches(value, EmailRX) {
+		return false
+	}
+	_, err := mail.ParseAddress(value)
+	return err == nil
+}
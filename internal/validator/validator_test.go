@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator(t *testing.T) {
+	v := New()
+	assert.True(t, v.Valid())
+
+	v.Check(true, "url", "is required")
+	assert.True(t, v.Valid())
+
+	v.Check(false, "url", "is required")
+	v.Check(false, "url", "must be an absolute url")
+	v.Merge(map[string]string{"url": "must use https", "method": "is required"})
+	assert.False(t, v.Valid())
+	assert.Equal(t, map[string]string{"url": "is required", "method": "is required"}, v.Errors)
+}
+
+func TestIsEmail(t *testing.T) {
+	assert.True(t, IsEmail("jojo@gmail.com"))
+	assert.True(t, IsEmail("jojo+alerts@mail.simplemon.dev"))
+	assert.False(t, IsEmail("jojo"))
+	assert.False(t, IsEmail("jojo@"))
+	assert.False(t, IsEmail("Jojo <jojo@gmail.com>"))
+	assert.False(t, IsEmail("jojo@gmail..com"))
+}
+
+func TestPermittedValue(t *testing.T) {
+	assert.True(t, PermittedValue("GET", "GET", "POST"))
+	assert.False(t, PermittedValue("FETCH", "GET", "POST"))
+	assert.True(t, PermittedValue(2, 1, 2, 3))
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS check_results (
+    check_result_id BIGSERIAL PRIMARY KEY,
+    monitor_id INTEGER NOT NULL,
+    checked_at timestamp(3) with time zone NOT NULL DEFAULT NOW(),
+    success BOOLEAN NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    response_size BIGINT NOT NULL DEFAULT 0,
+    error_class TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS check_results_monitor_id_checked_at_idx ON check_results (monitor_id, checked_at DESC);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS incidents (
+    incident_id BIGSERIAL PRIMARY KEY,
+    monitor_id INTEGER NOT NULL,
+    failing_since timestamp(3) with time zone NOT NULL,
+    opened_at timestamp(3) with time zone NOT NULL DEFAULT NOW(),
+    resolved_at timestamp(3) with time zone,
+    last_failure_at timestamp(3) with time zone NOT NULL,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+-- a monitor can have only one open incident at a time
+CREATE UNIQUE INDEX IF NOT EXISTS incidents_open_monitor_id_idx ON incidents (monitor_id) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS incidents_monitor_id_opened_at_idx ON incidents (monitor_id, opened_at DESC);
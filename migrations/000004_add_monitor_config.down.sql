@@ -0,0 +1 @@
+ALTER TABLE monitors DROP COLUMN IF EXISTS config;
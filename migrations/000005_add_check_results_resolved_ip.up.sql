@@ -0,0 +1 @@
+ALTER TABLE check_results ADD COLUMN IF NOT EXISTS resolved_ip TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS check_results_certificate_idx;
+ALTER TABLE check_results DROP COLUMN IF EXISTS cert_issuer;
+ALTER TABLE check_results DROP COLUMN IF EXISTS cert_expires_at;
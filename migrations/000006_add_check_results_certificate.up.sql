@@ -0,0 +1,3 @@
+ALTER TABLE check_results ADD COLUMN IF NOT EXISTS cert_expires_at timestamp(0) with time zone;
+ALTER TABLE check_results ADD COLUMN IF NOT EXISTS cert_issuer TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS check_results_certificate_idx ON check_results (monitor_id, checked_at DESC) WHERE cert_expires_at IS NOT NULL;
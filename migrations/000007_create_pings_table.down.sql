@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS monitors_heartbeat_url_idx;
+DROP TABLE IF EXISTS pings;
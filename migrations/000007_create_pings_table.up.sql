@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS pings (
+    ping_id BIGSERIAL PRIMARY KEY,
+    monitor_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    received_at timestamp(3) with time zone NOT NULL DEFAULT NOW(),
+    duration_ms BIGINT,
+    message TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS pings_monitor_id_kind_received_at_idx ON pings (monitor_id, kind, received_at DESC);
+-- the ping url is the secret token of the heartbeat monitors, so it must identify a single monitor
+CREATE UNIQUE INDEX IF NOT EXISTS monitors_heartbeat_url_idx ON monitors (url) WHERE type = 'heartbeat';
@@ -0,0 +1,2 @@
+ALTER TABLE check_results DROP COLUMN IF EXISTS actual_value;
+ALTER TABLE check_results DROP COLUMN IF EXISTS failed_assertion;
@@ -0,0 +1,2 @@
+ALTER TABLE check_results ADD COLUMN IF NOT EXISTS failed_assertion TEXT NOT NULL DEFAULT '';
+ALTER TABLE check_results ADD COLUMN IF NOT EXISTS actual_value TEXT NOT NULL DEFAULT '';
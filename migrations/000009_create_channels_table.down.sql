@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS monitor_channels;
+DROP TABLE IF EXISTS channels;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS channels (
+    channel_id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+-- monitor_id is not unique in the monitors table, so it can not be a foreign key
+CREATE TABLE IF NOT EXISTS monitor_channels (
+    monitor_id INTEGER NOT NULL,
+    channel_id BIGINT NOT NULL REFERENCES channels ON DELETE CASCADE,
+    PRIMARY KEY (monitor_id, channel_id)
+);
+CREATE INDEX IF NOT EXISTS monitor_channels_channel_id_idx ON monitor_channels (channel_id);
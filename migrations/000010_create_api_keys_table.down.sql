@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS channels_user_email_idx;
+ALTER TABLE channels DROP COLUMN IF EXISTS user_email;
+DROP TABLE IF EXISTS api_keys;
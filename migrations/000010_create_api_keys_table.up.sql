@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    api_key_id BIGSERIAL PRIMARY KEY,
+    user_email TEXT NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    last_used_at timestamp(0) with time zone
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_email_idx ON api_keys (user_email);
+-- the channels created before the API keys have no user, only the admin can see them
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS user_email TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS channels_user_email_idx ON channels (user_email);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS channels_org_id_idx;
+ALTER TABLE channels DROP COLUMN IF EXISTS org_id;
+DROP INDEX IF EXISTS monitors_org_id_type_url_method_idx;
+ALTER TABLE monitors DROP CONSTRAINT IF EXISTS monitors_pkey;
+ALTER TABLE monitors ADD CONSTRAINT monitor_id PRIMARY KEY (user_email, type, url, method);
+DROP INDEX IF EXISTS monitors_org_id_idx;
+ALTER TABLE monitors DROP COLUMN IF EXISTS org_id;
+DROP VIEW IF EXISTS org_roles;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    org_id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id BIGINT NOT NULL REFERENCES organizations ON DELETE CASCADE,
+    user_email TEXT NOT NULL,
+    role TEXT NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, user_email)
+);
+CREATE INDEX IF NOT EXISTS org_members_user_email_idx ON org_members (user_email);
+CREATE TABLE IF NOT EXISTS teams (
+    team_id BIGSERIAL PRIMARY KEY,
+    org_id BIGINT NOT NULL REFERENCES organizations ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id BIGINT NOT NULL REFERENCES teams ON DELETE CASCADE,
+    user_email TEXT NOT NULL,
+    PRIMARY KEY (team_id, user_email)
+);
+CREATE INDEX IF NOT EXISTS team_members_user_email_idx ON team_members (user_email);
+-- roles of each user in each organization, given by its membership or by its teams
+CREATE OR REPLACE VIEW org_roles AS
+    SELECT org_id, user_email, role FROM org_members
+    UNION ALL
+    SELECT t.org_id, tm.user_email, t.role FROM teams t JOIN team_members tm ON tm.team_id = t.team_id;
+
+-- the monitors of each existing user are moved to a personal organization owned by the user
+ALTER TABLE monitors ADD COLUMN IF NOT EXISTS org_id BIGINT REFERENCES organizations;
+WITH users AS (
+    SELECT user_email FROM monitors
+    UNION
+    SELECT user_email FROM api_keys
+    UNION
+    SELECT user_email FROM channels WHERE user_email <> ''
+), orgs AS (
+    INSERT INTO organizations (name)
+    SELECT user_email FROM users
+    RETURNING org_id, name
+)
+INSERT INTO org_members (org_id, user_email, role)
+SELECT org_id, name, 'owner' FROM orgs;
+UPDATE monitors m SET org_id = o.org_id
+FROM organizations o
+WHERE o.name = m.user_email AND m.org_id IS NULL;
+ALTER TABLE monitors ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS monitors_org_id_idx ON monitors (org_id);
+-- the same monitor must not be created twice in an organization by its different members
+ALTER TABLE monitors DROP CONSTRAINT IF EXISTS monitor_id;
+ALTER TABLE monitors ADD PRIMARY KEY (monitor_id);
+CREATE UNIQUE INDEX IF NOT EXISTS monitors_org_id_type_url_method_idx ON monitors (org_id, type, url, method);
+-- the channels belong to an organization, like the monitors, and are deleted with it. The channels
+-- without user stay without organization and only the admin can see them
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS org_id BIGINT REFERENCES organizations ON DELETE CASCADE;
+UPDATE channels c SET org_id = o.org_id
+FROM organizations o
+WHERE o.name = c.user_email AND c.org_id IS NULL;
+CREATE INDEX IF NOT EXISTS channels_org_id_idx ON channels (org_id);
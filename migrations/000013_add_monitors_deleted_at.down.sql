@@ -0,0 +1,7 @@
+DELETE FROM monitors WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS monitors_heartbeat_url_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS monitors_heartbeat_url_idx ON monitors (url) WHERE type = 'heartbeat';
+DROP INDEX IF EXISTS monitors_org_id_type_url_method_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS monitors_org_id_type_url_method_idx ON monitors (org_id, type, url, method);
+DROP INDEX IF EXISTS monitors_deleted_at_idx;
+ALTER TABLE monitors DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,9 @@
+-- the deleted monitors are kept, with their history, until the retention period is over
+ALTER TABLE monitors ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
+CREATE INDEX IF NOT EXISTS monitors_deleted_at_idx ON monitors (deleted_at) WHERE deleted_at IS NOT NULL;
+-- a deleted monitor must not prevent creating it again, so only the monitors that are not deleted
+-- must be unique
+DROP INDEX IF EXISTS monitors_org_id_type_url_method_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS monitors_org_id_type_url_method_idx ON monitors (org_id, type, url, method) WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS monitors_heartbeat_url_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS monitors_heartbeat_url_idx ON monitors (url) WHERE type = 'heartbeat' AND deleted_at IS NULL;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations of the database, applied in the order of their
+// version by the internal/migrate package. Each version has a <version>_<name>.up.sql file and
+// the <version>_<name>.down.sql file that reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS